@@ -0,0 +1,112 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+	"github.com/andrewstucki/actions-testing/templater/github"
+	"github.com/andrewstucki/actions-testing/templater/prompt"
+)
+
+var autoApprove bool
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:     "apply",
+	Aliases: []string{"apply-settings"},
+	Short:   "Apply the changes needed to bring GitHub in line with the configuration",
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			fmt.Printf("error reading configuration file: %v\n", err)
+			os.Exit(1)
+		}
+
+		var cfg config.ConfigFile
+		err = yaml.Unmarshal(data, &cfg)
+		if err != nil {
+			fmt.Printf("error unmarshaling configuration file: %v\n", err)
+			os.Exit(1)
+		}
+
+		settings, err := githubSettings(cfg)
+		if err != nil {
+			fmt.Printf("error reading Github settings: %v\n", err)
+			os.Exit(1)
+		}
+
+		client, err := github.GetClient(authOptions(cfg.GithubInfo.Host))
+		if err != nil {
+			fmt.Printf("error getting Github client: %v\n", err)
+			os.Exit(1)
+		}
+
+		settings.SecretValue = prompt.AskSecret
+
+		plan, err := client.PlanSettings(cmd.Context(), cfg.GithubInfo.Organization, cfg.GithubInfo.Repository, settings)
+		if err != nil {
+			fmt.Printf("error planning Github changes: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := plan.Write(os.Stdout); err != nil {
+			fmt.Printf("error writing plan: %v\n", err)
+			os.Exit(1)
+		}
+		if plan.Empty() {
+			return
+		}
+
+		if !autoApprove {
+			confirmed, err := prompt.Confirm("Do you wish to apply these changes")
+			if err != nil {
+				fmt.Printf("error confirming changes: %v\n", err)
+				os.Exit(1)
+			}
+			if !confirmed {
+				fmt.Print("apply canceled\n")
+				os.Exit(1)
+			}
+		}
+
+		if err := plan.Apply(cmd.Context()); err != nil {
+			fmt.Printf("error applying changes: %v\n", err)
+			recoverRepository(cmd.Context(), client, cfg, err, false)
+			os.Exit(1)
+		}
+		for _, change := range plan.Changes {
+			fmt.Printf("%s %s\n", change.Action, change.Resource)
+		}
+	},
+}
+
+// reportPartialApply prints which changes of a partially applied plan were
+// completed and which remain, returning the *github.ApplyError if err is one.
+func reportPartialApply(err error) *github.ApplyError {
+	var applyErr *github.ApplyError
+	if !errors.As(err, &applyErr) {
+		return nil
+	}
+
+	for _, change := range applyErr.Applied {
+		fmt.Printf("completed: %s %s\n", change.Action, change.Resource)
+	}
+	for _, change := range applyErr.Remaining {
+		fmt.Printf("remaining: %s %s\n", change.Action, change.Resource)
+	}
+	return applyErr
+}
+
+func init() {
+	applyCmd.Flags().BoolVar(&autoApprove, "auto-approve", false, "Skip the confirmation prompt before applying changes.")
+
+	rootCmd.AddCommand(applyCmd)
+}
@@ -0,0 +1,59 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+	"github.com/andrewstucki/actions-testing/templater/github"
+)
+
+// authStatusCmd represents the auth status command
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which GitHub account and token source templater uses",
+	Run: func(cmd *cobra.Command, args []string) {
+		// the configured host is used when there's a configuration file
+		var cfg config.ConfigFile
+		data, err := os.ReadFile(configFile)
+		if err == nil {
+			err = yaml.Unmarshal(data, &cfg)
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("error reading configuration file: %v\n", err)
+			os.Exit(1)
+		}
+
+		options := authOptions(cfg.GithubInfo.Host)
+		hostname, err := github.Hostname(options.Host)
+		if err != nil {
+			fmt.Printf("error reading host: %v\n", err)
+			os.Exit(1)
+		}
+
+		client, err := github.GetClient(options)
+		if err != nil {
+			fmt.Printf("error getting Github client: %v\n", err)
+			os.Exit(1)
+		}
+
+		user, err := client.User(cmd.Context())
+		if err != nil {
+			fmt.Printf("error getting user: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("logged in to %s as %s, token from %s\n", hostname, user, client.AuthSource())
+	},
+}
+
+func init() {
+	authCmd.AddCommand(authStatusCmd)
+}
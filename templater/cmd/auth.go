@@ -0,0 +1,18 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// authCmd represents the auth command
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Inspect GitHub authentication",
+}
+
+func init() {
+	rootCmd.AddCommand(authCmd)
+}
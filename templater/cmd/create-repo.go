@@ -4,6 +4,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -12,12 +13,15 @@ import (
 
 	"github.com/andrewstucki/actions-testing/templater/config"
 	"github.com/andrewstucki/actions-testing/templater/github"
+	"github.com/andrewstucki/actions-testing/templater/prompt"
 )
 
+var rollbackRepo bool
+
 // createRepoCmd represents the create-repo command
 var createRepoCmd = &cobra.Command{
 	Use:   "create-repo",
-	Short: "A brief description of your command",
+	Short: "Create the Github repository, or resume setting up a partially created one",
 	Run: func(cmd *cobra.Command, args []string) {
 		data, err := os.ReadFile(configFile)
 		if err != nil {
@@ -32,20 +36,61 @@ var createRepoCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		client, err := github.GetClient()
+		settings, err := githubSettings(cfg)
+		if err != nil {
+			fmt.Printf("error reading Github settings: %v\n", err)
+			os.Exit(1)
+		}
+
+		client, err := github.GetClient(authOptions(cfg.GithubInfo.Host))
 		if err != nil {
 			fmt.Printf("error getting Github client: %v\n", err)
 			os.Exit(1)
 		}
 
-		_, err = client.InitializeRepository(cmd.Context(), cfg.GithubInfo.Organization, cfg.GithubInfo.Repository)
+		_, err = client.InitializeRepository(cmd.Context(), cfg.GithubInfo.Organization, cfg.GithubInfo.Repository, settings)
 		if err != nil {
 			fmt.Printf("error initializing Github repo: %v\n", err)
+			recoverRepository(cmd.Context(), client, cfg, err, rollbackRepo)
 			os.Exit(1)
 		}
 	},
 }
 
+// recoverRepository reports the progress of a partially initialized
+// repository and, if it was created during this run, offers to roll it
+// back by deleting it, without prompting when rollback is set. Otherwise
+// rerunning picks up the remaining steps.
+func recoverRepository(ctx context.Context, client *github.Client, cfg config.ConfigFile, err error, rollback bool) {
+	applyErr := reportPartialApply(err)
+	if applyErr == nil {
+		return
+	}
+
+	if applyErr.CreatedRepository() {
+		confirmed := rollback
+		if !confirmed {
+			message := fmt.Sprintf("Do you wish to roll back by deleting %s/%s, which was created by this run", cfg.GithubInfo.Organization, cfg.GithubInfo.Repository)
+			if confirmed, err = prompt.Confirm(message); err != nil {
+				fmt.Printf("error confirming rollback: %v\n", err)
+			}
+		}
+
+		if confirmed {
+			if err := client.DeleteRepository(ctx, cfg.GithubInfo.Organization, cfg.GithubInfo.Repository); err != nil {
+				fmt.Printf("error rolling back Github repo: %v\n", err)
+				return
+			}
+			fmt.Print("rolled back Github repo\n")
+			return
+		}
+	}
+
+	fmt.Print("rerun to resume the remaining steps\n")
+}
+
 func init() {
+	createRepoCmd.Flags().BoolVar(&rollbackRepo, "rollback", false, "Delete the repository without prompting if it was created by this run and setup fails.")
+
 	rootCmd.AddCommand(createRepoCmd)
 }
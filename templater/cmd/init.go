@@ -20,6 +20,7 @@ import (
 var (
 	skipTidy       bool
 	initializeRepo bool
+	initRollback   bool
 )
 
 // initCmd represents the init command
@@ -44,27 +45,10 @@ var initCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		info := templates.TemplateInfo{
-			Copyright:            cfg.License.Copyright,
-			License:              cfg.License.License,
-			Organization:         cfg.GithubInfo.Organization,
-			Repository:           cfg.GithubInfo.Repository,
-			BackportBranches:     cfg.Backports.Branches,
-			Versions:             cfg.Backports.Versions,
-			BackportBot:          cfg.Backports.Bot.Name,
-			BackportBotTokenVar:  cfg.Backports.Bot.TokenVariable,
-			Label:                cfg.Backports.Label,
-			LabelMapper:          cfg.Backports.Mappings,
-			LicenseManagement:    true,
-			Backports:            true,
-			AutoApproveBackports: true,
-		}
-
-		for _, project := range cfg.Projects {
-			info.Projects = append(info.Projects, templates.ProjectInfo{
-				Name:      project.Name,
-				Changelog: project.Changelog,
-			})
+		info := templateInfo(*cfg)
+		if err := info.NormalizeAndValidate(); err != nil {
+			fmt.Printf("error validating project: %v\n", err)
+			os.Exit(1)
 		}
 
 		if err := templates.RenderTo(cfg.GithubInfo.Repository, info); err != nil {
@@ -93,9 +77,13 @@ var initCmd = &cobra.Command{
 				os.Exit(1)
 			}
 
-			if _, err := exec.Command("go", "mod", "tidy").CombinedOutput(); err != nil {
-				fmt.Printf("error running go mod tidy: %v\n", err)
-				os.Exit(1)
+			for _, project := range info.ProjectsWithLanguage(templates.LanguageGo) {
+				tidy := exec.Command("go", "mod", "tidy")
+				tidy.Dir = project.Directory
+				if _, err := tidy.CombinedOutput(); err != nil {
+					fmt.Printf("error running go mod tidy in %q: %v\n", project.Directory, err)
+					os.Exit(1)
+				}
 			}
 
 			if _, err := exec.Command("git", "add", ".").CombinedOutput(); err != nil {
@@ -125,19 +113,26 @@ var initCmd = &cobra.Command{
 		}
 
 		if initializeRepo {
-			client, err := github.GetClient()
+			settings, err := githubSettings(*cfg)
+			if err != nil {
+				fmt.Printf("error reading Github settings: %v\n", err)
+				os.Exit(1)
+			}
+
+			client, err := github.GetClient(authOptions(cfg.GithubInfo.Host))
 			if err != nil {
 				fmt.Printf("error getting Github client: %v\n", err)
 				os.Exit(1)
 			}
 
-			url, err := client.InitializeRepository(cmd.Context(), cfg.GithubInfo.Organization, cfg.GithubInfo.Repository)
+			url, err := client.InitializeRepository(cmd.Context(), cfg.GithubInfo.Organization, cfg.GithubInfo.Repository, settings)
 			if err != nil {
 				fmt.Printf("error initializing Github repo: %v\n", err)
+				recoverRepository(cmd.Context(), client, *cfg, err, initRollback)
 				os.Exit(1)
 			}
 
-			secrets, confirmed, err := prompt.RunSecretSync(*cfg)
+			secrets, confirmed, err := collectSecrets(cmd.Context(), *cfg, false)
 			if err != nil {
 				fmt.Printf("error getting secrets: %v\n", err)
 				os.Exit(1)
@@ -149,11 +144,8 @@ var initCmd = &cobra.Command{
 					os.Exit(1)
 				}
 
-				for _, secret := range secrets {
-					if err := client.SetEncryptedSecret(cmd.Context(), secret.Name, secret.Value); err != nil {
-						fmt.Printf("error setting %q: %v\n", secret.Name, err)
-						os.Exit(1)
-					}
+				if !syncSecrets(cmd.Context(), client, secrets) {
+					os.Exit(1)
 				}
 			}
 
@@ -173,6 +165,7 @@ var initCmd = &cobra.Command{
 func init() {
 	initCmd.Flags().BoolVarP(&skipTidy, "skip-tidy", "s", false, "Skip cleaning up the rendered output files")
 	initCmd.Flags().BoolVar(&initializeRepo, "initialize-repo", false, "Initialize repo")
+	initCmd.Flags().BoolVar(&initRollback, "rollback", false, "Delete the repository without prompting if it was created by this run and setup fails.")
 
 	rootCmd.AddCommand(initCmd)
 }
@@ -0,0 +1,89 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+	"github.com/andrewstucki/actions-testing/templater/github"
+)
+
+var (
+	pruneLabels  bool
+	labelsDryRun bool
+)
+
+// labelsSyncCmd represents the labels sync command
+var labelsSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Create and update repository labels from the configuration",
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			fmt.Printf("error reading configuration file: %v\n", err)
+			os.Exit(1)
+		}
+
+		var cfg config.ConfigFile
+		err = yaml.Unmarshal(data, &cfg)
+		if err != nil {
+			fmt.Printf("error unmarshaling configuration file: %v\n", err)
+			os.Exit(1)
+		}
+
+		settings, err := github.DesiredSettings(cfg)
+		if err != nil {
+			fmt.Printf("error reading Github settings: %v\n", err)
+			os.Exit(1)
+		}
+		settings.PruneLabels = pruneLabels
+
+		client, err := github.GetClient(authOptions(cfg.GithubInfo.Host))
+		if err != nil {
+			fmt.Printf("error getting Github client: %v\n", err)
+			os.Exit(1)
+		}
+
+		plan, err := client.PlanLabels(cmd.Context(), cfg.GithubInfo.Organization, cfg.GithubInfo.Repository, settings)
+		if err != nil {
+			fmt.Printf("error planning label changes: %v\n", err)
+			os.Exit(1)
+		}
+
+		if labelsDryRun {
+			if err := plan.Write(os.Stdout); err != nil {
+				fmt.Printf("error writing plan: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if plan.Empty() {
+			fmt.Print("labels are up to date\n")
+			return
+		}
+		if err := plan.Apply(cmd.Context()); err != nil {
+			fmt.Printf("error syncing labels: %v\n", err)
+			if reportPartialApply(err) != nil {
+				fmt.Print("rerun to resume the remaining changes\n")
+			}
+			os.Exit(1)
+		}
+		for _, change := range plan.Changes {
+			fmt.Printf("%s %s\n", change.Action, change.Resource)
+		}
+	},
+}
+
+func init() {
+	labelsSyncCmd.Flags().BoolVar(&pruneLabels, "prune", false, "Delete repository labels that aren't configured.")
+	labelsSyncCmd.Flags().BoolVar(&labelsDryRun, "dry-run", false, "Only show the changes that would be made.")
+
+	labelsCmd.AddCommand(labelsSyncCmd)
+}
@@ -0,0 +1,18 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// labelsCmd represents the labels command
+var labelsCmd = &cobra.Command{
+	Use:   "labels",
+	Short: "Manage repository labels",
+}
+
+func init() {
+	rootCmd.AddCommand(labelsCmd)
+}
@@ -0,0 +1,58 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+	"github.com/andrewstucki/actions-testing/templater/licenses"
+)
+
+var checkDirectory string
+
+// licenseCheckCmd represents the license check command
+var licenseCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check third-party dependency licenses against the configured license policy",
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			fmt.Printf("error reading configuration file: %v\n", err)
+			os.Exit(1)
+		}
+
+		var cfg config.ConfigFile
+		err = yaml.Unmarshal(data, &cfg)
+		if err != nil {
+			fmt.Printf("error unmarshaling configuration file: %v\n", err)
+			os.Exit(1)
+		}
+
+		dependencies, err := licenses.Dependencies(cmd.Context(), checkDirectory, cfg.LicenseReport.Ignore)
+		if err != nil {
+			fmt.Printf("error collecting dependencies: %v\n", err)
+			os.Exit(1)
+		}
+
+		violations := licenses.CheckPolicy(cfg.LicensePolicy, dependencies)
+		for _, violation := range violations {
+			fmt.Printf("license policy violation: %v\n", violation)
+		}
+
+		if len(violations) != 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	licenseCheckCmd.Flags().StringVarP(&checkDirectory, "dir", "d", ".", "Directory of the Go module to check.")
+
+	licenseCmd.AddCommand(licenseCheckCmd)
+}
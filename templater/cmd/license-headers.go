@@ -0,0 +1,61 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+	"github.com/andrewstucki/actions-testing/templater/licenses"
+)
+
+var checkHeaders bool
+
+// licenseHeadersCmd represents the license headers command
+var licenseHeadersCmd = &cobra.Command{
+	Use:   "headers",
+	Short: "Write or verify SPDX license headers in source files",
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := os.ReadFile(licenseConfigFile)
+		if err != nil {
+			fmt.Printf("error reading license configuration file: %v\n", err)
+			os.Exit(1)
+		}
+
+		var cfg config.LicenseHeaderConfig
+		err = yaml.Unmarshal(data, &cfg)
+		if err != nil {
+			fmt.Printf("error unmarshaling license configuration file: %v\n", err)
+			os.Exit(1)
+		}
+
+		changed, err := licenses.UpdateHeaders(".", cfg, checkHeaders)
+		if err != nil {
+			fmt.Printf("error updating license headers: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, file := range changed {
+			if checkHeaders {
+				fmt.Printf("missing or outdated license header: %s\n", file)
+			} else {
+				fmt.Printf("updated license header: %s\n", file)
+			}
+		}
+
+		if checkHeaders && len(changed) != 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	licenseHeadersCmd.Flags().BoolVar(&checkHeaders, "check", false, "Only verify license headers, exiting non-zero if any are missing")
+
+	licenseCmd.AddCommand(licenseHeadersCmd)
+}
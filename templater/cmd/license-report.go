@@ -0,0 +1,97 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+	"github.com/andrewstucki/actions-testing/templater/licenses"
+)
+
+var (
+	reportFormat    string
+	reportTemplate  string
+	reportOutput    string
+	reportDirectory string
+	reportIgnore    []string
+)
+
+// licenseReportCmd represents the license report command
+var licenseReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Generate a report of third-party dependency licenses",
+	Run: func(cmd *cobra.Command, args []string) {
+		ignore := reportIgnore
+
+		data, err := os.ReadFile(configFile)
+		if err != nil && !os.IsNotExist(err) {
+			fmt.Printf("error reading configuration file: %v\n", err)
+			os.Exit(1)
+		}
+		if err == nil {
+			var cfg config.ConfigFile
+			if err := yaml.Unmarshal(data, &cfg); err != nil {
+				fmt.Printf("error unmarshaling configuration file: %v\n", err)
+				os.Exit(1)
+			}
+			ignore = append(ignore, cfg.LicenseReport.Ignore...)
+		}
+
+		dependencies, err := licenses.Dependencies(cmd.Context(), reportDirectory, ignore)
+		if err != nil {
+			fmt.Printf("error collecting dependencies: %v\n", err)
+			os.Exit(1)
+		}
+
+		var buffer bytes.Buffer
+		switch reportFormat {
+		case "markdown":
+			var tmpl []byte
+			if reportTemplate != "" {
+				tmpl, err = os.ReadFile(reportTemplate)
+				if err != nil {
+					fmt.Printf("error reading report template: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			err = licenses.WriteMarkdown(&buffer, string(tmpl), dependencies)
+		case "json":
+			err = licenses.WriteJSON(&buffer, dependencies)
+		case "csv":
+			err = licenses.WriteCSV(&buffer, dependencies)
+		default:
+			err = fmt.Errorf("unsupported format %q, must be one of markdown, json, csv", reportFormat)
+		}
+		if err != nil {
+			fmt.Printf("error writing report: %v\n", err)
+			os.Exit(1)
+		}
+
+		if reportOutput == "" {
+			fmt.Print(buffer.String())
+			return
+		}
+
+		if err := os.WriteFile(reportOutput, buffer.Bytes(), 0644); err != nil {
+			fmt.Printf("error writing report file: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	licenseReportCmd.Flags().StringVarP(&reportFormat, "format", "f", "markdown", "Report format, one of markdown, json or csv.")
+	licenseReportCmd.Flags().StringVarP(&reportTemplate, "template", "t", "", "Template used to render markdown reports, defaults to a built-in template.")
+	licenseReportCmd.Flags().StringVarP(&reportOutput, "output", "o", "", "File to write the report to, defaults to stdout.")
+	licenseReportCmd.Flags().StringVarP(&reportDirectory, "dir", "d", ".", "Directory of the Go module to report on.")
+	licenseReportCmd.Flags().StringSliceVar(&reportIgnore, "ignore", nil, "Module path prefixes to exclude from the report.")
+
+	licenseCmd.AddCommand(licenseReportCmd)
+}
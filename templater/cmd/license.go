@@ -0,0 +1,22 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var licenseConfigFile string
+
+// licenseCmd represents the license command
+var licenseCmd = &cobra.Command{
+	Use:   "license",
+	Short: "Manage license headers and third-party license reports",
+}
+
+func init() {
+	licenseCmd.PersistentFlags().StringVar(&licenseConfigFile, "license-config", ".licenseupdater.yaml", "Location for the license header configuration file.")
+
+	rootCmd.AddCommand(licenseCmd)
+}
@@ -0,0 +1,62 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+	"github.com/andrewstucki/actions-testing/templater/github"
+)
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Show the changes needed to bring GitHub in line with the configuration",
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			fmt.Printf("error reading configuration file: %v\n", err)
+			os.Exit(1)
+		}
+
+		var cfg config.ConfigFile
+		err = yaml.Unmarshal(data, &cfg)
+		if err != nil {
+			fmt.Printf("error unmarshaling configuration file: %v\n", err)
+			os.Exit(1)
+		}
+
+		settings, err := githubSettings(cfg)
+		if err != nil {
+			fmt.Printf("error reading Github settings: %v\n", err)
+			os.Exit(1)
+		}
+
+		client, err := github.GetClient(authOptions(cfg.GithubInfo.Host))
+		if err != nil {
+			fmt.Printf("error getting Github client: %v\n", err)
+			os.Exit(1)
+		}
+
+		plan, err := client.PlanSettings(cmd.Context(), cfg.GithubInfo.Organization, cfg.GithubInfo.Repository, settings)
+		if err != nil {
+			fmt.Printf("error planning Github changes: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := plan.Write(os.Stdout); err != nil {
+			fmt.Printf("error writing plan: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+}
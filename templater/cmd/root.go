@@ -6,15 +6,24 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 
 	"github.com/andrewstucki/actions-testing/templater/config"
+	"github.com/andrewstucki/actions-testing/templater/github"
+	"github.com/andrewstucki/actions-testing/templater/secrets"
 	"github.com/andrewstucki/actions-testing/templater/templates"
 )
 
-var configFile string
+var (
+	configFile     string
+	githubHost     string
+	tokenFile      string
+	keyringTimeout time.Duration
+)
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -34,28 +43,7 @@ var rootCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		info := templates.TemplateInfo{
-			Copyright:            cfg.License.Copyright,
-			License:              cfg.License.License,
-			Organization:         cfg.GithubInfo.Organization,
-			Repository:           cfg.GithubInfo.Repository,
-			BackportBranches:     cfg.Backports.Branches,
-			Versions:             cfg.Backports.Versions,
-			Label:                cfg.Backports.Label,
-			LabelMapper:          cfg.Backports.Mappings,
-			BackportBot:          cfg.Backports.Bot.Name,
-			BackportBotTokenVar:  cfg.Backports.Bot.TokenVariable,
-			LicenseManagement:    true,
-			Backports:            true,
-			AutoApproveBackports: true,
-		}
-
-		for _, project := range cfg.Projects {
-			info.Projects = append(info.Projects, templates.ProjectInfo{
-				Name:      project.Name,
-				Changelog: project.Changelog,
-			})
-		}
+		info := templateInfo(cfg)
 
 		if err := templates.Update.RenderTo(".", info); err != nil {
 			fmt.Printf("error rendering templates: %v\n", err)
@@ -64,6 +52,89 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+// templateInfo maps the configuration file to the info used
+// when rendering templates.
+func templateInfo(cfg config.ConfigFile) templates.TemplateInfo {
+	info := templates.TemplateInfo{
+		Copyright:            cfg.License.Copyright,
+		License:              cfg.License.License,
+		Language:             templates.Language(cfg.Language),
+		Source:               cfg.Source,
+		Organization:         cfg.GithubInfo.Organization,
+		Repository:           cfg.GithubInfo.Repository,
+		BackportBranches:     cfg.Backports.Branches,
+		Versions:             cfg.Backports.Versions,
+		Label:                cfg.Backports.Label,
+		LabelMapper:          cfg.Backports.Mappings,
+		BackportBot:          cfg.Backports.Bot.Name,
+		BackportBotTokenVar:  cfg.Backports.Bot.TokenVariable,
+		LicenseManagement:    true,
+		Backports:            true,
+		AutoApproveBackports: true,
+	}
+
+	for _, label := range cfg.Labels {
+		info.Labels = append(info.Labels, templates.LabelInfo{
+			Name:        label.Name,
+			Color:       label.Color,
+			Description: label.Description,
+		})
+	}
+
+	for _, project := range cfg.Projects {
+		info.Projects = append(info.Projects, templates.ProjectInfo{
+			Name:      project.Name,
+			Changelog: project.Changelog,
+			Directory: project.Directory,
+			Module:    project.Module,
+			Language:  templates.Language(project.Language),
+			Scaffold:  templates.Scaffold(project.Scaffold),
+		})
+	}
+
+	return info
+}
+
+// githubSettings builds the desired GitHub state from the configuration,
+// requiring the status checks of the workflows rendered next to it.
+func githubSettings(cfg config.ConfigFile) (github.Settings, error) {
+	settings, err := github.DesiredSettings(cfg)
+	if err != nil {
+		return github.Settings{}, err
+	}
+
+	if cfg.GithubInfo.RequireStatusChecks != nil && !*cfg.GithubInfo.RequireStatusChecks {
+		return settings, nil
+	}
+
+	checks, err := github.WorkflowChecks(workflowsDirectory())
+	if err != nil {
+		return github.Settings{}, fmt.Errorf("reading workflow status checks: %w", err)
+	}
+	settings.Rulesets = append(settings.Rulesets, github.StatusCheckRulesets(cfg, checks)...)
+
+	return settings, nil
+}
+
+// workflowsDirectory returns the directory of the workflows rendered next
+// to the configuration file.
+func workflowsDirectory() string {
+	return filepath.Join(filepath.Dir(configFile), ".github", "workflows")
+}
+
+// authOptions returns where to look up the GitHub token according to the
+// global flags, the --host flag takes precedence over the configured host.
+func authOptions(host string) github.AuthOptions {
+	if githubHost != "" {
+		host = githubHost
+	}
+	return github.AuthOptions{
+		Host:           host,
+		TokenFile:      tokenFile,
+		KeyringTimeout: keyringTimeout,
+	}
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -75,4 +146,7 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", ".template.yaml", "Location for the template configuration file.")
+	rootCmd.PersistentFlags().StringVar(&githubHost, "host", "", "GitHub host to use instead of the one configured, defaults to github.com.")
+	rootCmd.PersistentFlags().StringVar(&tokenFile, "token-file", "", "Read the GitHub token from this file instead of the environment or gh configuration.")
+	rootCmd.PersistentFlags().DurationVar(&keyringTimeout, "keyring-timeout", secrets.DefaultKeyringTimeout, "How long to wait on the keyring when looking up the GitHub token or secret values.")
 }
@@ -0,0 +1,95 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+	"github.com/andrewstucki/actions-testing/templater/github"
+	"github.com/andrewstucki/actions-testing/templater/secrets"
+)
+
+var (
+	auditFormat     string
+	auditStaleAfter time.Duration
+)
+
+// secretsAuditCmd represents the secrets audit command
+var secretsAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Report missing, unused and stale repository secrets",
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			fmt.Printf("error reading configuration file: %v\n", err)
+			os.Exit(1)
+		}
+
+		var cfg config.ConfigFile
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			fmt.Printf("error unmarshaling configuration file: %v\n", err)
+			os.Exit(1)
+		}
+
+		declared, err := secrets.Declared(cfg)
+		if err != nil {
+			fmt.Printf("error reading secrets: %v\n", err)
+			os.Exit(1)
+		}
+
+		references, err := secrets.WorkflowReferences(workflowsDirectory())
+		if err != nil {
+			fmt.Printf("error reading workflows: %v\n", err)
+			os.Exit(1)
+		}
+
+		client, err := github.GetClient(authOptions(cfg.GithubInfo.Host))
+		if err != nil {
+			fmt.Printf("error getting Github client: %v\n", err)
+			os.Exit(1)
+		}
+
+		existing, err := client.ListSecrets(cmd.Context(), cfg.GithubInfo.Organization, cfg.GithubInfo.Repository)
+		if err != nil {
+			fmt.Printf("error listing secrets: %v\n", err)
+			os.Exit(1)
+		}
+
+		var staleBefore time.Time
+		if auditStaleAfter > 0 {
+			staleBefore = time.Now().Add(-auditStaleAfter)
+		}
+		report := secrets.Audit(declared, references, existing, staleBefore)
+
+		switch auditFormat {
+		case "text":
+			err = report.Write(os.Stdout)
+		case "json":
+			err = report.WriteJSON(os.Stdout)
+		default:
+			err = fmt.Errorf("unsupported format %q, must be one of text, json", auditFormat)
+		}
+		if err != nil {
+			fmt.Printf("error writing report: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(report.Missing) != 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	secretsAuditCmd.Flags().StringVarP(&auditFormat, "format", "f", "text", "Report format, one of text or json.")
+	secretsAuditCmd.Flags().DurationVar(&auditStaleAfter, "stale-after", 90*24*time.Hour, "Report repository secrets not updated within this duration as stale, 0 disables the check.")
+
+	secretsCmd.AddCommand(secretsAuditCmd)
+}
@@ -0,0 +1,152 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+	"github.com/andrewstucki/actions-testing/templater/prompt"
+	"github.com/andrewstucki/actions-testing/templater/secrets"
+)
+
+const secretsFileHeader = `# Secret values synced by "templater sync-secrets", keyed by secret name or
+# by "ENVIRONMENT/NAME" for environment secrets. Empty values are left out.
+`
+
+// secretsEditCmd represents the secrets edit command
+var secretsEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit the encrypted secrets file in $EDITOR",
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			fmt.Printf("error reading configuration file: %v\n", err)
+			os.Exit(1)
+		}
+
+		var cfg config.ConfigFile
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			fmt.Printf("error unmarshaling configuration file: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(cfg.SecretsFile.Recipients) == 0 {
+			fmt.Print("error editing secrets: secrets_file.recipients must list the age public keys to encrypt the file for\n")
+			os.Exit(1)
+		}
+
+		declared, err := secrets.Declared(cfg)
+		if err != nil {
+			fmt.Printf("error reading secrets: %v\n", err)
+			os.Exit(1)
+		}
+
+		values, err := readSecretsFile(cfg)
+		if err != nil {
+			fmt.Printf("error editing secrets: %v\n", err)
+			os.Exit(1)
+		}
+		original := maps.Clone(values)
+
+		// list every declared secret so there's a placeholder to fill in
+		for _, secret := range declared {
+			if _, ok := values[secrets.FileKey(secret)]; !ok {
+				values[secrets.FileKey(secret)] = ""
+			}
+		}
+
+		edited, err := editValues(values)
+		if err != nil {
+			fmt.Printf("error editing secrets: %v\n", err)
+			os.Exit(1)
+		}
+
+		maps.DeleteFunc(edited, func(_, value string) bool {
+			return strings.TrimSpace(value) == ""
+		})
+		if maps.Equal(original, edited) {
+			fmt.Print("no changes to secrets\n")
+			return
+		}
+
+		path := secrets.FilePath(cfg.SecretsFile, filepath.Dir(configFile))
+		if err := secrets.WriteFile(path, edited, cfg.SecretsFile.Recipients); err != nil {
+			fmt.Printf("error writing secrets file: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("updated %s\n", path)
+	},
+}
+
+// editValues opens the values in the user's editor through a private
+// temporary file, which is removed once the editor exits. The editor is
+// reopened on the same file when its contents fail to parse, so the edits
+// aren't lost.
+func editValues(values map[string]string) (map[string]string, error) {
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.CreateTemp("", "templater-secrets-*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(secretsFileHeader + string(data)); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	command := strings.Fields(editor)
+
+	for {
+		run := exec.Command(command[0], append(command[1:], file.Name())...)
+		run.Stdin, run.Stdout, run.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := run.Run(); err != nil {
+			return nil, fmt.Errorf("running %s: %w", editor, err)
+		}
+
+		data, err = os.ReadFile(file.Name())
+		if err != nil {
+			return nil, err
+		}
+		edited, err := secrets.ParseValues(data)
+		if err == nil {
+			return edited, nil
+		}
+
+		fmt.Printf("error parsing secrets: %v\n", err)
+		retry, confirmErr := prompt.Confirm("Do you wish to edit the secrets again")
+		if confirmErr != nil || !retry {
+			return nil, err
+		}
+	}
+}
+
+func init() {
+	secretsEditCmd.Flags().StringVar(&secretsIdentity, "identity", "", "age identity used to decrypt the secrets file, overrides the configured one.")
+
+	secretsCmd.AddCommand(secretsEditCmd)
+}
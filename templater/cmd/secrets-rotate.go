@@ -0,0 +1,103 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/andrewstucki/actions-testing/templater/github"
+	"github.com/andrewstucki/actions-testing/templater/prompt"
+	"github.com/andrewstucki/actions-testing/templater/secrets"
+)
+
+var (
+	rotateFleet   string
+	rotateFromEnv string
+)
+
+// secretsRotateCmd represents the secrets rotate command
+var secretsRotateCmd = &cobra.Command{
+	Use:   "rotate NAME",
+	Short: "Set a new value for a repository secret across a fleet of repositories",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		if err := secrets.ValidateName(name); err != nil {
+			fmt.Printf("error invalid secret %q: %v\n", name, err)
+			os.Exit(1)
+		}
+
+		fleet, err := secrets.LoadFleet(rotateFleet)
+		if err != nil {
+			fmt.Printf("error reading fleet file: %v\n", err)
+			os.Exit(1)
+		}
+
+		var value string
+		if rotateFromEnv != "" {
+			value = os.Getenv(rotateFromEnv)
+		} else if value, err = prompt.AskSecret(name); err != nil {
+			fmt.Printf("error getting secret value: %v\n", err)
+			os.Exit(1)
+		}
+		if value = strings.TrimSpace(value); value == "" {
+			fmt.Printf("error secret %q has no value\n", name)
+			os.Exit(1)
+		}
+
+		// fleets can span hosts, so clients are created as they're needed.
+		// The host of the fleet entry wins over --host, which is only the
+		// fallback for entries without one.
+		clients := map[string]*github.Client{}
+		rotations := secrets.Rotate(cmd.Context(), &fleet, name, func(ctx context.Context, host, organization, repo string) error {
+			client, ok := clients[host]
+			if !ok {
+				options := authOptions(host)
+				if host != "" {
+					options.Host = host
+				}
+
+				var err error
+				if client, err = github.GetClient(options); err != nil {
+					return fmt.Errorf("getting Github client: %w", err)
+				}
+				clients[host] = client
+			}
+
+			if _, err := client.SetRepository(ctx, organization, repo); err != nil {
+				return err
+			}
+			return client.SetEncryptedSecret(ctx, name, value)
+		}, time.Now())
+
+		if err := secrets.WriteRotations(os.Stdout, rotations); err != nil {
+			fmt.Printf("error writing results: %v\n", err)
+			os.Exit(1)
+		}
+		if err := secrets.SaveFleet(rotateFleet, fleet); err != nil {
+			fmt.Printf("error writing fleet file: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, rotation := range rotations {
+			if rotation.Err != nil {
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func init() {
+	secretsRotateCmd.Flags().StringVar(&rotateFleet, "fleet", "", "Fleet file listing the repositories to rotate the secret on.")
+	secretsRotateCmd.Flags().StringVar(&rotateFromEnv, "from-env", "", "Read the new value from this environment variable instead of prompting for it.")
+	_ = secretsRotateCmd.MarkFlagRequired("fleet")
+
+	secretsCmd.AddCommand(secretsRotateCmd)
+}
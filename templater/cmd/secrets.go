@@ -0,0 +1,59 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+	"github.com/andrewstucki/actions-testing/templater/secrets"
+)
+
+var secretsIdentity string
+
+// secretsCmd represents the secrets command
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Manage the secrets used by the repository workflows",
+}
+
+func init() {
+	rootCmd.AddCommand(secretsCmd)
+}
+
+// readSecretsFile decrypts the configured secrets file, returning no values
+// if there's no file configured or it hasn't been created yet.
+func readSecretsFile(cfg config.ConfigFile) (map[string]string, error) {
+	if !secrets.FileEnabled(cfg.SecretsFile) {
+		return map[string]string{}, nil
+	}
+
+	path := secrets.FilePath(cfg.SecretsFile, filepath.Dir(configFile))
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+
+	identity, err := secretsIdentityFile(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := secrets.ReadFile(path, identity)
+	if err != nil {
+		return nil, fmt.Errorf("reading secrets file: %w", err)
+	}
+	return values, nil
+}
+
+func secretsIdentityFile(cfg config.ConfigFile) (string, error) {
+	if secretsIdentity != "" {
+		return secretsIdentity, nil
+	}
+	return secrets.IdentityFile(cfg.SecretsFile)
+}
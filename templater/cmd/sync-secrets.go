@@ -4,8 +4,11 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
@@ -13,12 +16,19 @@ import (
 	"github.com/andrewstucki/actions-testing/templater/config"
 	"github.com/andrewstucki/actions-testing/templater/github"
 	"github.com/andrewstucki/actions-testing/templater/prompt"
+	"github.com/andrewstucki/actions-testing/templater/secrets"
+)
+
+var (
+	syncSecretsYes         bool
+	syncSecretsForce       bool
+	syncSecretsConcurrency int
 )
 
 // syncSecretsCmd represents the sync-secrets command
 var syncSecretsCmd = &cobra.Command{
 	Use:   "sync-secrets",
-	Short: "A brief description of your command",
+	Short: "Prompt for and set the secrets declared in the configuration",
 	Run: func(cmd *cobra.Command, args []string) {
 		data, err := os.ReadFile(configFile)
 		if err != nil {
@@ -33,31 +43,108 @@ var syncSecretsCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		secrets, confirmed, err := prompt.RunSecretSync(cfg)
+		secrets, confirmed, err := collectSecrets(cmd.Context(), cfg, syncSecretsYes)
 		if err != nil {
 			fmt.Printf("error getting secrets: %v\n", err)
 			os.Exit(1)
 		}
+		if len(secrets) == 0 {
+			fmt.Print("no secrets to sync\n")
+			return
+		}
 		if !confirmed {
 			fmt.Print("sync canceled\n")
 			os.Exit(1)
 		}
 
-		client, err := github.GetRepoClient(cmd.Context(), cfg.GithubInfo.Organization, cfg.GithubInfo.Repository)
+		client, err := github.GetRepoClient(cmd.Context(), authOptions(cfg.GithubInfo.Host), cfg.GithubInfo.Organization, cfg.GithubInfo.Repository)
 		if err != nil {
 			fmt.Printf("error getting Github client: %v\n", err)
 			os.Exit(1)
 		}
 
-		for _, secret := range secrets {
-			if err := client.SetEncryptedSecret(cmd.Context(), secret.Name, secret.Value); err != nil {
-				fmt.Printf("error setting %q: %v\n", secret.Name, err)
-				os.Exit(1)
-			}
+		if !syncSecrets(cmd.Context(), client, secrets) {
+			os.Exit(1)
 		}
 	},
 }
 
 func init() {
+	syncSecretsCmd.Flags().BoolVarP(&syncSecretsYes, "yes", "y", false, "Don't prompt, secrets without a configured source are skipped or fail if required")
+	syncSecretsCmd.Flags().BoolVar(&syncSecretsForce, "force", false, "Set every secret, even the ones whose value was already synced.")
+	syncSecretsCmd.Flags().IntVar(&syncSecretsConcurrency, "concurrency", 4, "Maximum number of secrets to set at once.")
+	syncSecretsCmd.Flags().StringVar(&secretsIdentity, "identity", "", "age identity used to decrypt the secrets file, overrides the configured one.")
 	rootCmd.AddCommand(syncSecretsCmd)
 }
+
+// collectSecrets resolves the declared secrets from their sources or the
+// encrypted secrets file, prompting for the rest and for confirmation
+// unless nonInteractive is set.
+func collectSecrets(ctx context.Context, cfg config.ConfigFile, nonInteractive bool) ([]secrets.Value, bool, error) {
+	declared, err := secrets.Declared(cfg)
+	if err != nil {
+		return nil, false, err
+	}
+
+	stored, err := readSecretsFile(cfg)
+	if err != nil {
+		return nil, false, err
+	}
+
+	ask := func(info config.SecretInfo) (string, error) {
+		if value, ok := stored[secrets.FileKey(info)]; ok {
+			return value, nil
+		}
+		if nonInteractive {
+			return "", nil
+		}
+		return prompt.AskDeclaredSecret(info)
+	}
+
+	values, err := secrets.Collect(ctx, declared, filepath.Dir(configFile), keyringTimeout, ask)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(values) == 0 || nonInteractive {
+		return values, len(values) != 0, nil
+	}
+
+	names := []string{}
+	for _, secret := range values {
+		names = append(names, fmt.Sprintf("%s on the %s", secret.Name, secrets.Target(secret.SecretInfo)))
+	}
+	confirmed, err := prompt.Confirm(fmt.Sprintf("Do you wish to set %s", strings.Join(names, " and ")))
+	return values, confirmed, err
+}
+
+// syncSecrets sets the secrets on the client's repository and prints the
+// outcome of each, returning whether they were all synced.
+func syncSecrets(ctx context.Context, client *github.Client, values []secrets.Value) bool {
+	var state *secrets.State
+	path, err := secrets.DefaultStatePath()
+	if err == nil {
+		state, err = secrets.LoadState(path)
+	}
+	if err != nil {
+		fmt.Printf("warning: not skipping unchanged secrets: %v\n", err)
+		state = nil
+	}
+
+	results := client.SyncSecrets(ctx, values, state, syncSecretsForce, syncSecretsConcurrency)
+	if err := secrets.WriteResults(os.Stdout, results); err != nil {
+		fmt.Printf("error writing results: %v\n", err)
+	}
+
+	if state != nil {
+		if err := state.Save(); err != nil {
+			fmt.Printf("warning: saving secrets state: %v\n", err)
+		}
+	}
+
+	for _, result := range results {
+		if result.Status == secrets.StatusFailed {
+			return false
+		}
+	}
+	return true
+}
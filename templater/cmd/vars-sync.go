@@ -0,0 +1,97 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+	"github.com/andrewstucki/actions-testing/templater/github"
+	"github.com/andrewstucki/actions-testing/templater/prompt"
+)
+
+var (
+	varsDryRun      bool
+	varsAutoApprove bool
+)
+
+// varsSyncCmd represents the vars sync command
+var varsSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Create and update Actions variables from the configuration",
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			fmt.Printf("error reading configuration file: %v\n", err)
+			os.Exit(1)
+		}
+
+		var cfg config.ConfigFile
+		err = yaml.Unmarshal(data, &cfg)
+		if err != nil {
+			fmt.Printf("error unmarshaling configuration file: %v\n", err)
+			os.Exit(1)
+		}
+
+		variables, err := github.DesiredVariables(cfg)
+		if err != nil {
+			fmt.Printf("error reading variables: %v\n", err)
+			os.Exit(1)
+		}
+
+		client, err := github.GetClient(authOptions(cfg.GithubInfo.Host))
+		if err != nil {
+			fmt.Printf("error getting Github client: %v\n", err)
+			os.Exit(1)
+		}
+
+		plan, err := client.PlanVariables(cmd.Context(), cfg.GithubInfo.Organization, cfg.GithubInfo.Repository, variables)
+		if err != nil {
+			fmt.Printf("error planning variable changes: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := plan.Write(os.Stdout); err != nil {
+			fmt.Printf("error writing plan: %v\n", err)
+			os.Exit(1)
+		}
+		if varsDryRun || plan.Empty() {
+			return
+		}
+
+		if !varsAutoApprove {
+			confirmed, err := prompt.Confirm("Do you wish to apply these changes")
+			if err != nil {
+				fmt.Printf("error confirming changes: %v\n", err)
+				os.Exit(1)
+			}
+			if !confirmed {
+				fmt.Print("sync canceled\n")
+				os.Exit(1)
+			}
+		}
+
+		if err := plan.Apply(cmd.Context()); err != nil {
+			fmt.Printf("error syncing variables: %v\n", err)
+			if reportPartialApply(err) != nil {
+				fmt.Print("rerun to resume the remaining changes\n")
+			}
+			os.Exit(1)
+		}
+		for _, change := range plan.Changes {
+			fmt.Printf("%s %s\n", change.Action, change.Resource)
+		}
+	},
+}
+
+func init() {
+	varsSyncCmd.Flags().BoolVar(&varsDryRun, "dry-run", false, "Only show the changes that would be made.")
+	varsSyncCmd.Flags().BoolVar(&varsAutoApprove, "auto-approve", false, "Skip the confirmation prompt before applying changes.")
+
+	varsCmd.AddCommand(varsSyncCmd)
+}
@@ -0,0 +1,18 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// varsCmd represents the vars command
+var varsCmd = &cobra.Command{
+	Use:   "vars",
+	Short: "Manage GitHub Actions configuration variables",
+}
+
+func init() {
+	rootCmd.AddCommand(varsCmd)
+}
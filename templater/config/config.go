@@ -3,6 +3,11 @@
 
 package config
 
+import (
+	"strings"
+	"time"
+)
+
 type LicenseInfo struct {
 	Copyright string `yaml:"copyright"`
 	License   string `yaml:"license"`
@@ -11,11 +16,62 @@ type LicenseInfo struct {
 type ProjectInfo struct {
 	Name      string `yaml:"name"`
 	Changelog string `yaml:"changelog"`
+	Directory string `yaml:"directory,omitempty"`
+	Module    string `yaml:"module,omitempty"`
+	Language  string `yaml:"language,omitempty"`
+	Scaffold  string `yaml:"scaffold,omitempty"`
+}
+
+type RepositorySettings struct {
+	Description            string   `yaml:"description,omitempty"`
+	Homepage               string   `yaml:"homepage,omitempty"`
+	Topics                 []string `yaml:"topics,omitempty"`
+	Visibility             string   `yaml:"visibility,omitempty"`
+	AllowAutoMerge         *bool    `yaml:"allow_auto_merge,omitempty"`
+	AllowMergeCommit       *bool    `yaml:"allow_merge_commit,omitempty"`
+	AllowSquashMerge       *bool    `yaml:"allow_squash_merge,omitempty"`
+	AllowRebaseMerge       *bool    `yaml:"allow_rebase_merge,omitempty"`
+	SquashMergeCommitTitle string   `yaml:"squash_merge_commit_title,omitempty"`
+	DeleteBranchOnMerge    *bool    `yaml:"delete_branch_on_merge,omitempty"`
+	HasWiki                *bool    `yaml:"has_wiki,omitempty"`
+	HasProjects            *bool    `yaml:"has_projects,omitempty"`
+	IsTemplate             *bool    `yaml:"is_template,omitempty"`
+}
+
+type WorkflowPermissions struct {
+	Default                      string `yaml:"default,omitempty"`
+	CanApprovePullRequestReviews *bool  `yaml:"can_approve_pull_request_reviews,omitempty"`
+}
+
+type BypassActorInfo struct {
+	ActorID   int64  `yaml:"actor_id,omitempty"`
+	ActorType string `yaml:"actor_type"`
+	Mode      string `yaml:"mode,omitempty"`
+}
+
+type RulesetInfo struct {
+	Name                   string            `yaml:"name"`
+	Target                 string            `yaml:"target,omitempty"`
+	Enforcement            string            `yaml:"enforcement,omitempty"`
+	Include                []string          `yaml:"include,omitempty"`
+	Exclude                []string          `yaml:"exclude,omitempty"`
+	MergeMethods           []string          `yaml:"merge_methods,omitempty"`
+	RequiredApprovals      *int              `yaml:"required_approvals,omitempty"`
+	RequireCodeOwnerReview bool              `yaml:"require_code_owner_review,omitempty"`
+	DismissStaleReviews    bool              `yaml:"dismiss_stale_reviews,omitempty"`
+	RequireLinearHistory   bool              `yaml:"require_linear_history,omitempty"`
+	RequireSignedCommits   bool              `yaml:"require_signed_commits,omitempty"`
+	BypassActors           []BypassActorInfo `yaml:"bypass_actors,omitempty"`
 }
 
 type GithubInfo struct {
-	Organization string `yaml:"organization"`
-	Repository   string `yaml:"repository"`
+	Host                string              `yaml:"host,omitempty"`
+	Organization        string              `yaml:"organization"`
+	Repository          string              `yaml:"repository"`
+	Settings            RepositorySettings  `yaml:"settings,omitempty"`
+	WorkflowPermissions WorkflowPermissions `yaml:"workflow_permissions,omitempty"`
+	Rulesets            []RulesetInfo       `yaml:"rulesets,omitempty"`
+	RequireStatusChecks *bool               `yaml:"require_status_checks,omitempty"`
 }
 
 type BotInfo struct {
@@ -31,14 +87,129 @@ type BackportInfo struct {
 	Bot      BotInfo           `yaml:"bot"`
 }
 
+type LicenseReportInfo struct {
+	Ignore []string `yaml:"ignore,omitempty"`
+}
+
+type LicensePolicyException struct {
+	Module  string `yaml:"module"`
+	License string `yaml:"license,omitempty"`
+	Reason  string `yaml:"reason,omitempty"`
+}
+
+type LicensePolicy struct {
+	Allowed    []string                 `yaml:"allowed,omitempty"`
+	Denied     []string                 `yaml:"denied,omitempty"`
+	Exceptions []LicensePolicyException `yaml:"exceptions,omitempty"`
+}
+
+type LabelInfo struct {
+	Name        string `yaml:"name"`
+	Color       string `yaml:"color"`
+	Description string `yaml:"description,omitempty"`
+}
+
+type KeyringSourceInfo struct {
+	Service string `yaml:"service"`
+	User    string `yaml:"user"`
+}
+
+type SecretSourceInfo struct {
+	Env     string             `yaml:"env,omitempty"`
+	File    string             `yaml:"file,omitempty"`
+	Keyring *KeyringSourceInfo `yaml:"keyring,omitempty"`
+	Command []string           `yaml:"command,omitempty"`
+}
+
+type SecretInfo struct {
+	Name         string           `yaml:"name"`
+	Description  string           `yaml:"description,omitempty"`
+	Scope        string           `yaml:"scope,omitempty"`
+	Environment  string           `yaml:"environment,omitempty"`
+	Visibility   string           `yaml:"visibility,omitempty"`
+	Repositories []string         `yaml:"repositories,omitempty"`
+	Dependabot   bool             `yaml:"dependabot,omitempty"`
+	Required     bool             `yaml:"required,omitempty"`
+	Workflows    []string         `yaml:"workflows,omitempty"`
+	Source       SecretSourceInfo `yaml:"source,omitempty"`
+}
+
+type SecretsFileInfo struct {
+	Path       string   `yaml:"path,omitempty"`
+	Identity   string   `yaml:"identity,omitempty"`
+	Recipients []string `yaml:"recipients,omitempty"`
+}
+
+type VariableInfo struct {
+	Name         string   `yaml:"name"`
+	Value        string   `yaml:"value"`
+	Scope        string   `yaml:"scope,omitempty"`
+	Environment  string   `yaml:"environment,omitempty"`
+	Visibility   string   `yaml:"visibility,omitempty"`
+	Repositories []string `yaml:"repositories,omitempty"`
+}
+
 type ConfigFile struct {
-	License    LicenseInfo   `yaml:"license"`
-	GithubInfo GithubInfo    `yaml:"github"`
-	Projects   []ProjectInfo `yaml:"projects"`
-	Backports  BackportInfo  `yaml:"backports"`
+	Language      string            `yaml:"language,omitempty"`
+	Source        string            `yaml:"source,omitempty"`
+	License       LicenseInfo       `yaml:"license"`
+	GithubInfo    GithubInfo        `yaml:"github"`
+	Projects      []ProjectInfo     `yaml:"projects"`
+	Backports     BackportInfo      `yaml:"backports"`
+	LicenseReport LicenseReportInfo `yaml:"license_report,omitempty"`
+	LicensePolicy LicensePolicy     `yaml:"license_policy,omitempty"`
+	Labels        []LabelInfo       `yaml:"labels,omitempty"`
+	Secrets       []SecretInfo      `yaml:"secrets,omitempty"`
+	SecretsFile   SecretsFileInfo   `yaml:"secrets_file,omitempty"`
+	Variables     []VariableInfo    `yaml:"variables,omitempty"`
+}
+
+type FleetRepository struct {
+	Name    string               `yaml:"name"`
+	Host    string               `yaml:"host,omitempty"`
+	Rotated map[string]time.Time `yaml:"rotated,omitempty"`
+}
+
+type FleetFile struct {
+	Host         string            `yaml:"host,omitempty"`
+	Repositories []FleetRepository `yaml:"repositories"`
+}
+
+type LicenseHeaderMatch struct {
+	Type      string `yaml:"type"`
+	Extension string `yaml:"extension"`
+	Directory string `yaml:"directory,omitempty"`
+	Short     bool   `yaml:"short"`
+	License   string `yaml:"license"`
+}
+
+type LicenseHeaderConfig struct {
+	Organization    string               `yaml:"organization"`
+	TopLevelLicense string               `yaml:"top_level_license"`
+	Matches         []LicenseHeaderMatch `yaml:"matches"`
+}
+
+// DefaultLabels are the labels the templated workflows rely on.
+var DefaultLabels = []LabelInfo{
+	{Name: "no-changelog", Color: "8f1402"},
+	{Name: "stale", Color: "8f1402"},
 }
 
-type Secret struct {
-	Name  string
-	Value string
+// WithDefaultLabels returns the default labels followed by the given
+// ones, a given label replaces the default label of the same name.
+func WithDefaultLabels(labels []LabelInfo) []LabelInfo {
+	merged := []LabelInfo{}
+	for _, label := range DefaultLabels {
+		overridden := false
+		for _, override := range labels {
+			if strings.EqualFold(override.Name, label.Name) {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged = append(merged, label)
+		}
+	}
+	return append(merged, labels...)
 }
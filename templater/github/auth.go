@@ -0,0 +1,159 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/andrewstucki/actions-testing/templater/secrets"
+)
+
+const githubHost = "github.com"
+
+// ErrNotFound is returned when the keyring has no token for the host.
+var ErrNotFound = secrets.ErrKeyringNotFound
+
+// AuthOptions configures where the GitHub token is looked up.
+type AuthOptions struct {
+	// Host is the GitHub host to authenticate against, github.com when
+	// empty. A URL can be given to use a scheme other than https.
+	Host string
+	// TokenFile, when set, is read for the token before anything else.
+	TokenFile string
+	// KeyringTimeout bounds the keyring lookup,
+	// secrets.DefaultKeyringTimeout is used when it's zero.
+	KeyringTimeout time.Duration
+}
+
+// Auth is a resolved GitHub token along with where it was found. User is
+// only known when the token came from the gh configuration, otherwise it's
+// looked up from the API when needed.
+type Auth struct {
+	User   string
+	Token  string
+	Source string
+}
+
+// ResolveAuth looks up a GitHub token, in order, from the token file,
+// the GH_TOKEN and GITHUB_TOKEN environment variables, a plaintext token in
+// the gh hosts.yml and finally the keyring entry gh stores it in. Hosts
+// other than github.com use GH_ENTERPRISE_TOKEN and GITHUB_ENTERPRISE_TOKEN
+// instead, like gh does.
+func ResolveAuth(options AuthOptions) (Auth, error) {
+	hostname, err := Hostname(options.Host)
+	if err != nil {
+		return Auth{}, err
+	}
+
+	if options.TokenFile != "" {
+		data, err := os.ReadFile(options.TokenFile)
+		if err != nil {
+			return Auth{}, fmt.Errorf("reading token file: %w", err)
+		}
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			return Auth{}, fmt.Errorf("token file %q is empty", options.TokenFile)
+		}
+		return Auth{Token: token, Source: options.TokenFile}, nil
+	}
+
+	variables := []string{"GH_TOKEN", "GITHUB_TOKEN"}
+	if hostname != githubHost {
+		variables = []string{"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"}
+	}
+	for _, variable := range variables {
+		if token := strings.TrimSpace(os.Getenv(variable)); token != "" {
+			return Auth{Token: token, Source: variable}, nil
+		}
+	}
+
+	hostsFile, err := ghHostsFile()
+	if err != nil {
+		return Auth{}, err
+	}
+	github, err := getGithubHost(hostsFile, hostname)
+	if err != nil {
+		return Auth{}, fmt.Errorf("no token set in %s and %w", strings.Join(variables, " or "), err)
+	}
+	if github.OAuthToken != "" {
+		return Auth{User: github.User, Token: github.OAuthToken, Source: hostsFile}, nil
+	}
+
+	service := "gh:" + hostname
+	token, err := secrets.KeyringGet(service, github.User, options.KeyringTimeout)
+	if err != nil {
+		return Auth{}, fmt.Errorf("fetching token: %w", err)
+	}
+	return Auth{User: github.User, Token: token, Source: fmt.Sprintf("keyring %q", service)}, nil
+}
+
+type host struct {
+	User       string `yaml:"user"`
+	OAuthToken string `yaml:"oauth_token"`
+}
+
+// ghHostsFile returns where gh keeps its hosts.yml, honoring GH_CONFIG_DIR
+// and XDG_CONFIG_HOME the same way gh does.
+func ghHostsFile() (string, error) {
+	if directory := os.Getenv("GH_CONFIG_DIR"); directory != "" {
+		return filepath.Join(directory, "hosts.yml"), nil
+	}
+	if directory := os.Getenv("XDG_CONFIG_HOME"); directory != "" {
+		return filepath.Join(directory, "gh", "hosts.yml"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "gh", "hosts.yml"), nil
+}
+
+func getGithubHost(configFile, hostname string) (host, error) {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return host{}, fmt.Errorf("reading config file: %w", err)
+	}
+
+	hosts := map[string]host{}
+	if err := yaml.Unmarshal(data, hosts); err != nil {
+		return host{}, fmt.Errorf("unmarshaling config file: %w", err)
+	}
+
+	// a plaintext token is enough on its own, the user is looked up from
+	// the API when it's needed
+	github, ok := hosts[hostname]
+	if !ok || (github.User == "" && github.OAuthToken == "") {
+		return host{}, fmt.Errorf("unable to find active user for %s", hostname)
+	}
+
+	return github, nil
+}
+
+// Hostname returns the hostname of a configured GitHub host, github.com
+// when it's empty.
+func Hostname(host string) (string, error) {
+	if host == "" {
+		return githubHost, nil
+	}
+	if !strings.Contains(host, "://") {
+		return host, nil
+	}
+
+	parsed, err := url.Parse(host)
+	if err != nil {
+		return "", fmt.Errorf("parsing host %q: %w", host, err)
+	}
+	if parsed.Host == "" {
+		return "", fmt.Errorf("host %q has no hostname", host)
+	}
+	return parsed.Host, nil
+}
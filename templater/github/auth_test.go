@@ -0,0 +1,170 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+func TestResolveAuth(t *testing.T) {
+	directory := t.TempDir()
+	hostsFile := filepath.Join(directory, "gh", "hosts.yml")
+	tokenFile := filepath.Join(directory, "token")
+
+	keyring.MockInit()
+	require.NoError(t, keyring.Set("gh:github.com", "octocat", "from-keyring"))
+	require.NoError(t, os.WriteFile(tokenFile, []byte("from-file\n"), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Dir(hostsFile), 0700))
+
+	writeHosts := func(t *testing.T, contents string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(hostsFile, []byte(contents), 0600))
+	}
+
+	t.Setenv("HOME", filepath.Join(directory, "home"))
+	t.Setenv("XDG_CONFIG_HOME", directory)
+	t.Setenv("GH_CONFIG_DIR", "")
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "")
+
+	writeHosts(t, "github.com:\n  user: octocat\n")
+
+	t.Run("keyring", func(t *testing.T) {
+		auth, err := ResolveAuth(AuthOptions{})
+		require.NoError(t, err)
+		require.Equal(t, Auth{User: "octocat", Token: "from-keyring", Source: `keyring "gh:github.com"`}, auth)
+	})
+
+	t.Run("hosts file token", func(t *testing.T) {
+		t.Cleanup(func() { writeHosts(t, "github.com:\n  user: octocat\n") })
+		writeHosts(t, "github.com:\n  user: octocat\n  oauth_token: from-hosts\n")
+
+		auth, err := ResolveAuth(AuthOptions{})
+		require.NoError(t, err)
+		require.Equal(t, Auth{User: "octocat", Token: "from-hosts", Source: hostsFile}, auth)
+	})
+
+	t.Run("hosts file token without user", func(t *testing.T) {
+		t.Cleanup(func() { writeHosts(t, "github.com:\n  user: octocat\n") })
+		writeHosts(t, "github.com:\n  oauth_token: from-hosts\n")
+
+		auth, err := ResolveAuth(AuthOptions{})
+		require.NoError(t, err)
+		require.Equal(t, Auth{Token: "from-hosts", Source: hostsFile}, auth)
+	})
+
+	t.Run("config dir", func(t *testing.T) {
+		configDir := filepath.Join(directory, "custom")
+		require.NoError(t, os.MkdirAll(configDir, 0700))
+		require.NoError(t, os.WriteFile(filepath.Join(configDir, "hosts.yml"), []byte("github.com:\n  user: hubot\n  oauth_token: from-config-dir\n"), 0600))
+		t.Setenv("GH_CONFIG_DIR", configDir)
+
+		auth, err := ResolveAuth(AuthOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "hubot", auth.User)
+		require.Equal(t, "from-config-dir", auth.Token)
+	})
+
+	t.Run("environment", func(t *testing.T) {
+		t.Setenv("GITHUB_TOKEN", "from-github-token")
+		auth, err := ResolveAuth(AuthOptions{})
+		require.NoError(t, err)
+		require.Equal(t, Auth{Token: "from-github-token", Source: "GITHUB_TOKEN"}, auth)
+
+		t.Setenv("GH_TOKEN", "from-gh-token")
+		auth, err = ResolveAuth(AuthOptions{})
+		require.NoError(t, err)
+		require.Equal(t, Auth{Token: "from-gh-token", Source: "GH_TOKEN"}, auth)
+
+		auth, err = ResolveAuth(AuthOptions{TokenFile: tokenFile})
+		require.NoError(t, err)
+		require.Equal(t, Auth{Token: "from-file", Source: tokenFile}, auth)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		writeHosts(t, "github.com:\n  user: nobody\n")
+		t.Cleanup(func() { writeHosts(t, "github.com:\n  user: octocat\n") })
+
+		_, err := ResolveAuth(AuthOptions{KeyringTimeout: time.Second})
+		require.ErrorIs(t, err, ErrNotFound)
+
+		_, err = ResolveAuth(AuthOptions{TokenFile: filepath.Join(directory, "missing")})
+		require.ErrorContains(t, err, "reading token file")
+	})
+}
+
+func TestClientUser(t *testing.T) {
+	client := newFakeGithub(t).client(t)
+	client.user = ""
+
+	user, err := client.User(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "user", user)
+}
+
+func TestEnterpriseClient(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/user" {
+			http.NotFound(w, r)
+			return
+		}
+		authorization = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"login":"octocat"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "from-github-token")
+	t.Setenv("GH_ENTERPRISE_TOKEN", "from-enterprise-token")
+
+	client, err := GetClient(AuthOptions{Host: server.URL})
+	require.NoError(t, err)
+	require.Equal(t, server.URL+"/api/v3/", client.BaseURL.String())
+	require.Equal(t, server.URL+"/api/uploads/", client.UploadURL.String())
+	require.Equal(t, "GH_ENTERPRISE_TOKEN", client.AuthSource())
+
+	user, err := client.User(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "octocat", user)
+	require.Equal(t, "Bearer from-enterprise-token", authorization)
+
+	t.Run("hostname", func(t *testing.T) {
+		client, err := GetClient(AuthOptions{Host: "github.example.com"})
+		require.NoError(t, err)
+		require.Equal(t, "https://github.example.com/api/v3/", client.BaseURL.String())
+
+		client, err = GetClient(AuthOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "https://api.github.com/", client.BaseURL.String())
+		require.Equal(t, "GITHUB_TOKEN", client.AuthSource())
+	})
+
+	t.Run("hosts file", func(t *testing.T) {
+		directory := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(directory, "hosts.yml"), []byte("github.com:\n  user: octocat\ngithub.example.com:\n  user: hubot\n"), 0600))
+		t.Setenv("GH_CONFIG_DIR", directory)
+		t.Setenv("GH_ENTERPRISE_TOKEN", "")
+		t.Setenv("GITHUB_ENTERPRISE_TOKEN", "")
+
+		keyring.MockInit()
+		require.NoError(t, keyring.Set("gh:github.example.com", "hubot", "from-keyring"))
+
+		auth, err := ResolveAuth(AuthOptions{Host: "https://github.example.com"})
+		require.NoError(t, err)
+		require.Equal(t, Auth{User: "hubot", Token: "from-keyring", Source: `keyring "gh:github.example.com"`}, auth)
+
+		_, err = ResolveAuth(AuthOptions{Host: "ghe.example.com"})
+		require.EqualError(t, err, "no token set in GH_ENTERPRISE_TOKEN or GITHUB_ENTERPRISE_TOKEN and unable to find active user for ghe.example.com")
+	})
+}
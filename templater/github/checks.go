@@ -0,0 +1,313 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v69/github"
+	"gopkg.in/yaml.v3"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+)
+
+const (
+	statusChecksRuleset         = "Required status checks"
+	backportStatusChecksRuleset = "Required backport status checks"
+	mainBranch                  = "main"
+)
+
+// WorkflowCheck is a status check reported by a workflow job that runs
+// on pull requests.
+type WorkflowCheck struct {
+	Name     string
+	Workflow string
+
+	branches       []string
+	branchesIgnore []string
+}
+
+// RunsOn returns whether the check runs for pull requests into the given
+// branch.
+func (c WorkflowCheck) RunsOn(branch string) bool {
+	if len(c.branches) != 0 {
+		return matchesBranchFilter(c.branches, branch)
+	}
+	if len(c.branchesIgnore) != 0 {
+		return !matchesBranchFilter(c.branchesIgnore, branch)
+	}
+	return true
+}
+
+type workflowFile struct {
+	On   yaml.Node              `yaml:"on"`
+	Jobs map[string]workflowJob `yaml:"jobs"`
+}
+
+type workflowJob struct {
+	Name     string `yaml:"name"`
+	Uses     string `yaml:"uses"`
+	Strategy struct {
+		Matrix yaml.Node `yaml:"matrix"`
+	} `yaml:"strategy"`
+}
+
+type pullRequestTrigger struct {
+	Branches       []string `yaml:"branches"`
+	BranchesIgnore []string `yaml:"branches-ignore"`
+	Paths          []string `yaml:"paths"`
+	PathsIgnore    []string `yaml:"paths-ignore"`
+}
+
+// WorkflowChecks parses every workflow in the given directory and returns
+// the checks reported by jobs that run on all pull requests, sorted by
+// name. Workflows filtered by paths are skipped since they don't report
+// a status on every pull request, as are jobs whose names can't be known
+// ahead of time.
+func WorkflowChecks(directory string) ([]WorkflowCheck, error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	checks := []WorkflowCheck{}
+	for _, entry := range entries {
+		extension := filepath.Ext(entry.Name())
+		if entry.IsDir() || (extension != ".yml" && extension != ".yaml") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(directory, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var workflow workflowFile
+		if err := yaml.Unmarshal(data, &workflow); err != nil {
+			return nil, fmt.Errorf("parsing workflow %q: %w", entry.Name(), err)
+		}
+
+		trigger, ok, err := pullRequestTriggerFor(workflow.On)
+		if err != nil {
+			return nil, fmt.Errorf("parsing workflow %q triggers: %w", entry.Name(), err)
+		}
+		if !ok || len(trigger.Paths) != 0 || len(trigger.PathsIgnore) != 0 {
+			continue
+		}
+
+		for id, job := range workflow.Jobs {
+			if job.Uses != "" {
+				continue
+			}
+
+			name := job.Name
+			if name == "" {
+				name = id
+			}
+			if strings.Contains(name, "${{") {
+				continue
+			}
+
+			names, ok := matrixCheckNames(name, &job.Strategy.Matrix)
+			if !ok {
+				continue
+			}
+			for _, name := range names {
+				checks = append(checks, WorkflowCheck{
+					Name:           name,
+					Workflow:       entry.Name(),
+					branches:       trigger.Branches,
+					branchesIgnore: trigger.BranchesIgnore,
+				})
+			}
+		}
+	}
+
+	slices.SortFunc(checks, func(a, b WorkflowCheck) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return checks, nil
+}
+
+// StatusCheckRulesets returns rulesets requiring the given checks on pull
+// requests into main and into every configured backport branch. Checks
+// that only run for some of those branches are only required on them.
+func StatusCheckRulesets(cfg config.ConfigFile, checks []WorkflowCheck) []github.RepositoryRuleset {
+	rulesets := []github.RepositoryRuleset{}
+
+	main := []string{}
+	for _, check := range checks {
+		if check.RunsOn(mainBranch) {
+			main = append(main, check.Name)
+		}
+	}
+	if len(main) != 0 {
+		rulesets = append(rulesets, statusCheckRuleset(statusChecksRuleset, []string{"~DEFAULT_BRANCH"}, main))
+	}
+
+	if len(cfg.Backports.Branches) == 0 {
+		return rulesets
+	}
+
+	include := []string{}
+	for _, branch := range cfg.Backports.Branches {
+		include = append(include, "refs/heads/"+branch)
+	}
+
+	backports := []string{}
+	for _, check := range checks {
+		runsOnAll := true
+		for _, branch := range cfg.Backports.Branches {
+			if !check.RunsOn(branch) {
+				runsOnAll = false
+				break
+			}
+		}
+		if runsOnAll {
+			backports = append(backports, check.Name)
+		}
+	}
+	if len(backports) != 0 {
+		rulesets = append(rulesets, statusCheckRuleset(backportStatusChecksRuleset, include, backports))
+	}
+
+	return rulesets
+}
+
+func statusCheckRuleset(name string, include, checks []string) github.RepositoryRuleset {
+	statusChecks := []*github.RuleStatusCheck{}
+	for _, check := range slices.Compact(slices.Sorted(slices.Values(checks))) {
+		statusChecks = append(statusChecks, &github.RuleStatusCheck{Context: check})
+	}
+
+	return normalizeRuleset(github.RepositoryRuleset{
+		Name:        name,
+		Target:      github.Ptr(github.RulesetTargetBranch),
+		Enforcement: github.RulesetEnforcementActive,
+		Conditions: &github.RepositoryRulesetConditions{
+			RefName: &github.RepositoryRulesetRefConditionParameters{
+				Include: include,
+				Exclude: []string{},
+			},
+		},
+		Rules: &github.RepositoryRulesetRules{
+			RequiredStatusChecks: &github.RequiredStatusChecksRuleParameters{
+				RequiredStatusChecks: statusChecks,
+			},
+		},
+	})
+}
+
+// pullRequestTriggerFor returns the pull_request trigger of a workflow's
+// "on" field, which can be a single event, a list of events or a map of
+// events to their filters.
+func pullRequestTriggerFor(on yaml.Node) (pullRequestTrigger, bool, error) {
+	var trigger pullRequestTrigger
+
+	switch on.Kind {
+	case yaml.ScalarNode:
+		return trigger, on.Value == "pull_request", nil
+	case yaml.SequenceNode:
+		for _, event := range on.Content {
+			if event.Value == "pull_request" {
+				return trigger, true, nil
+			}
+		}
+		return trigger, false, nil
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(on.Content); i += 2 {
+			if on.Content[i].Value != "pull_request" {
+				continue
+			}
+			if on.Content[i+1].Kind == yaml.MappingNode {
+				if err := on.Content[i+1].Decode(&trigger); err != nil {
+					return trigger, false, err
+				}
+			}
+			return trigger, true, nil
+		}
+	}
+	return trigger, false, nil
+}
+
+// matrixCheckNames returns the check names GitHub reports for a job with
+// the given matrix, it only supports matrices made of literal lists.
+func matrixCheckNames(name string, matrix *yaml.Node) ([]string, bool) {
+	if matrix.Kind == 0 {
+		return []string{name}, true
+	}
+	if matrix.Kind != yaml.MappingNode {
+		return nil, false
+	}
+
+	combinations := [][]string{{}}
+	for i := 0; i+1 < len(matrix.Content); i += 2 {
+		key, values := matrix.Content[i], matrix.Content[i+1]
+		if key.Value == "include" || key.Value == "exclude" || values.Kind != yaml.SequenceNode {
+			return nil, false
+		}
+
+		expanded := [][]string{}
+		for _, combination := range combinations {
+			for _, value := range values.Content {
+				if value.Kind != yaml.ScalarNode {
+					return nil, false
+				}
+				expanded = append(expanded, append(slices.Clone(combination), value.Value))
+			}
+		}
+		combinations = expanded
+	}
+
+	names := []string{}
+	for _, combination := range combinations {
+		if len(combination) == 0 {
+			names = append(names, name)
+			continue
+		}
+		names = append(names, fmt.Sprintf("%s (%s)", name, strings.Join(combination, ", ")))
+	}
+	return names, true
+}
+
+// matchesBranchFilter returns whether the branch matches the given
+// workflow branch filters, later "!" prefixed patterns exclude branches
+// matched by earlier ones.
+func matchesBranchFilter(patterns []string, branch string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		if globPattern(strings.TrimPrefix(pattern, "!")).MatchString(branch) {
+			matched = !negated
+		}
+	}
+	return matched
+}
+
+func globPattern(pattern string) *regexp.Regexp {
+	var expression strings.Builder
+	expression.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**"):
+			expression.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			expression.WriteString("[^/]*")
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		}
+	}
+	expression.WriteString("$")
+	return regexp.MustCompile(expression.String())
+}
@@ -0,0 +1,138 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+	"github.com/andrewstucki/actions-testing/templater/templates"
+)
+
+func TestWorkflowChecks(t *testing.T) {
+	directory := t.TempDir()
+
+	workflows := map[string]string{
+		"changelog.yml": `
+on:
+  pull_request:
+    branches: [main]
+jobs:
+  changed_files:
+    name: Check for changelog entry
+`,
+		"ci.yaml": `
+on: [push, pull_request]
+jobs:
+  test:
+    name: Build and test
+    strategy:
+      matrix:
+        directory: [".", "operator"]
+        go: ["1.23"]
+  lint: {}
+`,
+		"release.yml": `
+on:
+  pull_request:
+    branches: ["v*", "!v0.*"]
+jobs:
+  release:
+    name: Check release notes
+`,
+		"backport.yml": `
+on:
+  pull_request_target:
+jobs:
+  backport:
+    name: Backport PR
+`,
+		"paths.yml": `
+on:
+  pull_request:
+    paths: ["**/go.mod"]
+jobs:
+  check:
+    name: Check dependency licenses
+`,
+		"dynamic.yml": `
+on: pull_request
+jobs:
+  dynamic:
+    name: ${{ github.event_name }}
+  matrix:
+    name: Dynamic matrix
+    strategy:
+      matrix: ${{ fromJSON(needs.setup.outputs.matrix) }}
+  reusable:
+    uses: ./.github/workflows/reusable.yml
+`,
+		"README.md": "not a workflow",
+	}
+	for name, content := range workflows {
+		require.NoError(t, os.WriteFile(filepath.Join(directory, name), []byte(content), 0644))
+	}
+
+	checks, err := WorkflowChecks(directory)
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, check := range checks {
+		names = append(names, check.Name)
+	}
+	require.Equal(t, []string{
+		"Build and test (., 1.23)",
+		"Build and test (operator, 1.23)",
+		"Check for changelog entry",
+		"Check release notes",
+		"lint",
+	}, names)
+
+	rulesets := StatusCheckRulesets(config.ConfigFile{
+		Backports: config.BackportInfo{Branches: []string{"v1.0.x", "v1.1.x"}},
+	}, checks)
+
+	data, err := json.Marshal(rulesets)
+	require.NoError(t, err)
+	require.JSONEq(t, `[
+		{"name":"Required status checks","target":"branch","source":"","enforcement":"active","conditions":{"ref_name":{"include":["~DEFAULT_BRANCH"],"exclude":[]}},"rules":[{"type":"required_status_checks","parameters":{"do_not_enforce_on_create":false,"required_status_checks":[{"context":"Build and test (., 1.23)"},{"context":"Build and test (operator, 1.23)"},{"context":"Check for changelog entry"},{"context":"lint"}],"strict_required_status_checks_policy":false}}]},
+		{"name":"Required backport status checks","target":"branch","source":"","enforcement":"active","conditions":{"ref_name":{"include":["refs/heads/v1.0.x","refs/heads/v1.1.x"],"exclude":[]}},"rules":[{"type":"required_status_checks","parameters":{"do_not_enforce_on_create":false,"required_status_checks":[{"context":"Build and test (., 1.23)"},{"context":"Build and test (operator, 1.23)"},{"context":"Check release notes"},{"context":"lint"}],"strict_required_status_checks_policy":false}}]}
+	]`, string(data))
+}
+
+func TestWorkflowChecksRendered(t *testing.T) {
+	directory := t.TempDir()
+
+	require.NoError(t, (&templates.Renderer{IgnoreExecutable: true}).RenderTo(directory, templates.TemplateInfo{
+		Organization:      "org",
+		Repository:        "repo",
+		License:           "MIT",
+		LicenseManagement: true,
+		Backports:         true,
+	}))
+
+	checks, err := WorkflowChecks(filepath.Join(directory, ".github", "workflows"))
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, check := range checks {
+		names = append(names, check.Name)
+	}
+	require.Equal(t, []string{
+		"Build and test (.)",
+		"Check for changelog entry",
+		"Check license headers",
+	}, names)
+}
+
+func TestWorkflowChecksMissingDirectory(t *testing.T) {
+	checks, err := WorkflowChecks(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	require.Empty(t, checks)
+}
@@ -6,118 +6,54 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
-	"path"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/google/go-github/v69/github"
-	"github.com/zalando/go-keyring"
 	"golang.org/x/crypto/nacl/box"
-	"gopkg.in/yaml.v3"
 )
 
-var ErrNotFound = errors.New("secret not found in keyring")
-
-type TimeoutError struct {
-	message string
-}
-
-func (e *TimeoutError) Error() string {
-	return e.message
-}
-
-// from https://github.com/cli/cli/blob/af4acb380136fd106b38cc0ab404ff975bca9795/internal/keyring/keyring.go#L37C1-L59C2
-func getToken(service, user string) (string, error) {
-	ch := make(chan struct {
-		val string
-		err error
-	}, 1)
-	go func() {
-		defer close(ch)
-		val, err := keyring.Get(service, user)
-		ch <- struct {
-			val string
-			err error
-		}{val, err}
-	}()
-	select {
-	case res := <-ch:
-		if errors.Is(res.err, keyring.ErrNotFound) {
-			return "", ErrNotFound
-		}
-		return res.val, res.err
-	case <-time.After(3 * time.Second):
-		return "", &TimeoutError{"timeout while trying to get secret from keyring"}
-	}
-}
-
-type host struct {
-	User string `yaml:"user"`
-}
-
-func getGithubUser() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("getting home directory: %w", err)
-	}
-
-	configFile := path.Join(home, ".config", "gh", "hosts.yml")
-	data, err := os.ReadFile(configFile)
-	if err != nil {
-		return "", fmt.Errorf("reading config file: %w", err)
-	}
-
-	hosts := map[string]host{}
-	if err := yaml.Unmarshal(data, hosts); err != nil {
-		return "", fmt.Errorf("unmarshaling config file: %w", err)
-	}
-
-	github, ok := hosts["github.com"]
-	if !ok || github.User == "" {
-		return "", errors.New("unable to find active github user")
-	}
-
-	return github.User, nil
-}
-
-func getUserTokenForGithub() (string, string, error) {
-	user, err := getGithubUser()
-	if err != nil {
-		return "", "", err
-	}
-
-	token, err := getToken("gh:github.com", user)
-	if err != nil {
-		return "", "", fmt.Errorf("fetching token: %w", err)
-	}
-
-	return user, token, nil
-}
-
 type Client struct {
 	*github.Client
 
 	user string
+	auth string
+
+	organization string
+	repo         string
 
-	organization  string
-	repo          string
-	peerPublicKey [32]byte
-	key           *github.PublicKey
+	// cache guards what's cached for the repository, secrets can be set
+	// concurrently.
+	cache        sync.Mutex
+	repositoryID int64
+	environments map[string]bool
+	keys         map[string]*github.PublicKey
 }
 
-func GetClient() (*Client, error) {
-	user, token, err := getUserTokenForGithub()
+// GetClient returns a client authenticated with the first token found
+// according to the options. Hosts other than github.com are treated as
+// GitHub Enterprise Server instances.
+func GetClient(options AuthOptions) (*Client, error) {
+	auth, err := ResolveAuth(options)
 	if err != nil {
 		return nil, err
 	}
 
-	client := github.NewClient(nil).WithAuthToken(token)
-	return &Client{Client: client, user: user}, nil
+	client := github.NewClient(nil).WithAuthToken(auth.Token)
+	if hostname, _ := Hostname(options.Host); hostname != githubHost {
+		baseURL := options.Host
+		if !strings.Contains(baseURL, "://") {
+			baseURL = "https://" + baseURL
+		}
+		if client, err = client.WithEnterpriseURLs(baseURL, baseURL); err != nil {
+			return nil, fmt.Errorf("configuring enterprise URLs for %q: %w", options.Host, err)
+		}
+	}
+	return &Client{Client: client, user: auth.User, auth: auth.Source}, nil
 }
 
-func GetRepoClient(ctx context.Context, organization, repo string) (*Client, error) {
-	client, err := GetClient()
+func GetRepoClient(ctx context.Context, options AuthOptions, organization, repo string) (*Client, error) {
+	client, err := GetClient(options)
 	if err != nil {
 		return nil, err
 	}
@@ -125,72 +61,66 @@ func GetRepoClient(ctx context.Context, organization, repo string) (*Client, err
 	return client.SetRepository(ctx, organization, repo)
 }
 
-func (c *Client) InitializeRepository(ctx context.Context, organization, repo string) (string, error) {
-	userOrganization := organization
-	if c.user == organization {
-		userOrganization = ""
+// AuthSource returns where the client's token was found.
+func (c *Client) AuthSource() string {
+	return c.auth
+}
+
+// User returns the login of the authenticated user, looking it up when
+// the token didn't come with one.
+func (c *Client) User(ctx context.Context) (string, error) {
+	if c.user != "" {
+		return c.user, nil
 	}
 
-	createdRepo, _, err := c.Client.Repositories.Create(ctx, userOrganization, &github.Repository{
-		Name:                github.Ptr(repo),
-		AllowAutoMerge:      github.Ptr(true),
-		DeleteBranchOnMerge: github.Ptr(true),
-		HasWiki:             github.Ptr(false),
-		HasProjects:         github.Ptr(false),
-	})
+	user, _, err := c.Client.Users.Get(ctx, "")
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("getting authenticated user: %w", err)
 	}
+	c.user = user.GetLogin()
+	return c.user, nil
+}
 
-	_, _, err = c.Client.Repositories.CreateRuleset(ctx, organization, repo, github.RepositoryRuleset{
-		Name:        "Require PR",
-		Enforcement: github.RulesetEnforcementActive,
-		Conditions: &github.RepositoryRulesetConditions{
-			RefName: &github.RepositoryRulesetRefConditionParameters{
-				Include: []string{"~DEFAULT_BRANCH", "refs/heads/v**"},
-				Exclude: []string{},
-			},
-		},
-		Rules: &github.RepositoryRulesetRules{
-			Deletion:       &github.EmptyRuleParameters{},
-			NonFastForward: &github.EmptyRuleParameters{},
-			PullRequest: &github.PullRequestRuleParameters{
-				AllowedMergeMethods: []github.MergeMethod{
-					github.MergeMethodMerge, github.MergeMethodRebase, github.MergeMethodSquash,
-				},
-				DismissStaleReviewsOnPush:      false,
-				RequireCodeOwnerReview:         false,
-				RequireLastPushApproval:        false,
-				RequiredApprovingReviewCount:   1,
-				RequiredReviewThreadResolution: false,
-			},
-		},
-	})
-	if err != nil {
+// InitializeRepository creates the repository if it doesn't exist and
+// reconciles its settings, returning the repository's SSH URL. Secrets
+// are left alone since they're synced separately.
+func (c *Client) InitializeRepository(ctx context.Context, organization, repo string, settings Settings) (string, error) {
+	settings.Secrets = nil
+	if _, err := c.ApplySettings(ctx, organization, repo, settings); err != nil {
 		return "", err
 	}
 
-	// c.Client.Repositories.UpdateRequiredStatusChecks()
-
-	_, _, err = c.Client.Repositories.EditDefaultWorkflowPermissions(ctx, organization, repo, github.DefaultWorkflowPermissionRepository{
-		DefaultWorkflowPermissions:   github.Ptr("write"),
-		CanApprovePullRequestReviews: github.Ptr(true),
-	})
+	repository, _, err := c.Client.Repositories.Get(ctx, organization, repo)
 	if err != nil {
 		return "", err
 	}
 
-	return createdRepo.GetSSHURL(), nil
+	return repository.GetSSHURL(), nil
+}
+
+// DeleteRepository deletes the repository, it's used to roll back a
+// repository that was only partially initialized.
+func (c *Client) DeleteRepository(ctx context.Context, organization, repo string) error {
+	_, err := c.Client.Repositories.Delete(ctx, organization, repo)
+	return err
 }
 
 func (c *Client) SetRepository(ctx context.Context, organization, repo string) (*Client, error) {
-	var err error
+	c.useRepository(organization, repo)
 
-	c.organization, c.repo = organization, repo
-	c.key, c.peerPublicKey, err = c.getPublicKey(ctx)
+	_, err := c.repositoryPublicKey(ctx)
 	return c, err
 }
 
+// useRepository points the client at a repository, resetting everything
+// cached for the previous one.
+func (c *Client) useRepository(organization, repo string) {
+	c.organization, c.repo = organization, repo
+	c.repositoryID = 0
+	c.environments = map[string]bool{}
+	c.keys = map[string]*github.PublicKey{}
+}
+
 func (c *Client) SetEncryptedSecret(ctx context.Context, name, value string) error {
 	if c.organization == "" || c.repo == "" {
 		return errors.New("must set repository before setting encrypted secret")
@@ -201,32 +131,68 @@ func (c *Client) SetEncryptedSecret(ctx context.Context, name, value string) err
 		return nil
 	}
 
-	var rand io.Reader
-	encryptedBody, err := box.SealAnonymous(nil, []byte(value)[:], &c.peerPublicKey, rand)
+	key, err := c.repositoryPublicKey(ctx)
+	if err != nil {
+		return err
+	}
+	encrypted, err := encrypt(key, value)
 	if err != nil {
 		return err
 	}
 
-	encoded := base64.StdEncoding.EncodeToString(encryptedBody)
 	_, err = c.Client.Actions.CreateOrUpdateRepoSecret(ctx, c.organization, c.repo, &github.EncryptedSecret{
 		Name:           name,
-		EncryptedValue: encoded,
-		KeyID:          c.key.GetKeyID(),
+		EncryptedValue: encrypted,
+		KeyID:          key.GetKeyID(),
 	})
 	return err
 }
 
-func (c *Client) getPublicKey(ctx context.Context) (*github.PublicKey, [32]byte, error) {
-	var peerPubKey [32]byte
-	pubKey, _, err := c.Client.Actions.GetRepoPublicKey(ctx, c.organization, c.repo)
+func (c *Client) repositoryPublicKey(ctx context.Context) (*github.PublicKey, error) {
+	return c.publicKey("repo", func() (*github.PublicKey, *github.Response, error) {
+		return c.Client.Actions.GetRepoPublicKey(ctx, c.organization, c.repo)
+	})
+}
+
+// publicKey returns the public key cached under the given id, fetching it
+// on first use.
+func (c *Client) publicKey(id string, fetch func() (*github.PublicKey, *github.Response, error)) (*github.PublicKey, error) {
+	c.cache.Lock()
+	defer c.cache.Unlock()
+
+	if key, ok := c.keys[id]; ok {
+		return key, nil
+	}
+
+	key, _, err := fetch()
 	if err != nil {
-		return nil, peerPubKey, err
+		return nil, err
+	}
+	if c.keys == nil {
+		c.keys = map[string]*github.PublicKey{}
 	}
-	decodedPubKey, err := base64.StdEncoding.DecodeString(pubKey.GetKey())
+	c.keys[id] = key
+	return key, nil
+}
+
+// encrypt seals the value for the given public key, returning it base64
+// encoded as the secrets API expects.
+func encrypt(key *github.PublicKey, value string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(key.GetKey())
 	if err != nil {
-		return nil, peerPubKey, err
+		return "", err
 	}
-	copy(peerPubKey[:], decodedPubKey[0:32])
+	if len(decoded) != 32 {
+		return "", fmt.Errorf("invalid public key length %d", len(decoded))
+	}
+
+	var peerPublicKey [32]byte
+	copy(peerPublicKey[:], decoded)
 
-	return pubKey, peerPubKey, nil
+	var rand io.Reader
+	encrypted, err := box.SealAnonymous(nil, []byte(value), &peerPublicKey, rand)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(encrypted), nil
 }
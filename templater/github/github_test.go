@@ -0,0 +1,403 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v69/github"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// fakeGithub is an in-memory fake of the parts of the GitHub API that
+// templater manages, scoped to a single repository.
+type fakeGithub struct {
+	mutex sync.Mutex
+
+	repository  *github.Repository
+	rulesets    map[int64]*github.RepositoryRuleset
+	permissions *github.DefaultWorkflowPermissionRepository
+	labels      map[string]*github.Label
+	secrets     map[string]*github.EncryptedSecret
+	orgSecrets  []string
+	publicKey   *[32]byte
+
+	// environments and scopedSecrets track environment, organization and
+	// Dependabot secrets, keyed by the path they were set on.
+	environments  map[string]bool
+	scopedSecrets map[string]*github.EncryptedSecret
+	// updated records when each secret was last set, keyed by its path.
+	updated map[string]github.Timestamp
+	// variables are keyed by "repo/NAME", "environment/ENV/NAME" or
+	// "org/NAME".
+	variables map[string]*github.ActionsVariable
+
+	// failures maps "METHOD /path" to a status code returned instead
+	// of handling the request.
+	failures map[string]int
+	requests []string
+}
+
+func newFakeGithub(t *testing.T) *fakeGithub {
+	t.Helper()
+
+	publicKey, _, err := box.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	return &fakeGithub{
+		rulesets:  map[int64]*github.RepositoryRuleset{},
+		labels:    map[string]*github.Label{},
+		secrets:   map[string]*github.EncryptedSecret{},
+		publicKey: publicKey,
+		failures:  map[string]int{},
+
+		environments:  map[string]bool{},
+		scopedSecrets: map[string]*github.EncryptedSecret{},
+		variables:     map[string]*github.ActionsVariable{},
+		updated:       map[string]github.Timestamp{},
+	}
+}
+
+// client starts a server for the fake and returns a client pointed at it.
+func (f *fakeGithub) client(t *testing.T) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(f.handler())
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	baseURL, err := url.Parse(server.URL + "/")
+	require.NoError(t, err)
+	client.BaseURL = baseURL
+
+	return &Client{Client: client, user: "user"}
+}
+
+// writes returns every request that modified state, in order.
+func (f *fakeGithub) writes() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	writes := []string{}
+	for _, request := range f.requests {
+		if !strings.HasPrefix(request, http.MethodGet) {
+			writes = append(writes, request)
+		}
+	}
+	return writes
+}
+
+func (f *fakeGithub) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	handle := func(pattern string, fn func(w http.ResponseWriter, r *http.Request) any) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			f.mutex.Lock()
+			defer f.mutex.Unlock()
+
+			request := r.Method + " " + r.URL.Path
+			f.requests = append(f.requests, request)
+
+			if status, ok := f.failures[request]; ok {
+				w.WriteHeader(status)
+				_ = json.NewEncoder(w).Encode(map[string]string{"message": http.StatusText(status)})
+				return
+			}
+
+			response := fn(w, r)
+			if response == nil {
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(response)
+		})
+	}
+
+	notFound := func(w http.ResponseWriter) any {
+		w.WriteHeader(http.StatusNotFound)
+		return map[string]string{"message": "Not Found"}
+	}
+
+	decode := func(r *http.Request, v any) {
+		_ = json.NewDecoder(r.Body).Decode(v)
+	}
+
+	createRepository := func(w http.ResponseWriter, r *http.Request) any {
+		f.repository = &github.Repository{}
+		decode(r, f.repository)
+		f.repository.ID = github.Ptr(int64(1))
+		f.repository.SSHURL = github.Ptr("git@github.com:org/" + f.repository.GetName() + ".git")
+		f.permissions = &github.DefaultWorkflowPermissionRepository{
+			DefaultWorkflowPermissions:   github.Ptr("read"),
+			CanApprovePullRequestReviews: github.Ptr(false),
+		}
+		w.WriteHeader(http.StatusCreated)
+		return f.repository
+	}
+	handle("POST /orgs/{org}/repos", createRepository)
+	handle("POST /user/repos", createRepository)
+	handle("GET /user", func(w http.ResponseWriter, r *http.Request) any {
+		return &github.User{Login: github.Ptr("user")}
+	})
+
+	handle("GET /repos/{owner}/{repo}", func(w http.ResponseWriter, r *http.Request) any {
+		if f.repository == nil {
+			return notFound(w)
+		}
+		return f.repository
+	})
+	handle("DELETE /repos/{owner}/{repo}", func(w http.ResponseWriter, r *http.Request) any {
+		f.repository = nil
+		f.rulesets = map[int64]*github.RepositoryRuleset{}
+		f.labels = map[string]*github.Label{}
+		f.secrets = map[string]*github.EncryptedSecret{}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+	handle("PATCH /repos/{owner}/{repo}", func(w http.ResponseWriter, r *http.Request) any {
+		decode(r, f.repository)
+		return f.repository
+	})
+
+	handle("PUT /repos/{owner}/{repo}/topics", func(w http.ResponseWriter, r *http.Request) any {
+		var topics struct {
+			Names []string `json:"names"`
+		}
+		decode(r, &topics)
+		f.repository.Topics = topics.Names
+		return topics
+	})
+
+	handle("GET /repos/{owner}/{repo}/rulesets", func(w http.ResponseWriter, r *http.Request) any {
+		rulesets := []*github.RepositoryRuleset{}
+		for _, ruleset := range f.rulesets {
+			rulesets = append(rulesets, &github.RepositoryRuleset{ID: ruleset.ID, Name: ruleset.Name, Enforcement: ruleset.Enforcement})
+		}
+		return rulesets
+	})
+	handle("POST /repos/{owner}/{repo}/rulesets", func(w http.ResponseWriter, r *http.Request) any {
+		ruleset := &github.RepositoryRuleset{}
+		decode(r, ruleset)
+		ruleset.ID = github.Ptr(int64(len(f.rulesets) + 1))
+		f.rulesets[ruleset.GetID()] = ruleset
+		w.WriteHeader(http.StatusCreated)
+		return ruleset
+	})
+	handle("GET /repos/{owner}/{repo}/rulesets/{id}", func(w http.ResponseWriter, r *http.Request) any {
+		id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		ruleset, ok := f.rulesets[id]
+		if !ok {
+			return notFound(w)
+		}
+		return ruleset
+	})
+	handle("PUT /repos/{owner}/{repo}/rulesets/{id}", func(w http.ResponseWriter, r *http.Request) any {
+		id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		ruleset := &github.RepositoryRuleset{}
+		decode(r, ruleset)
+		ruleset.ID = github.Ptr(id)
+		f.rulesets[id] = ruleset
+		return ruleset
+	})
+	handle("DELETE /repos/{owner}/{repo}/rulesets/{id}", func(w http.ResponseWriter, r *http.Request) any {
+		id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		delete(f.rulesets, id)
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+
+	handle("GET /repos/{owner}/{repo}/actions/permissions/workflow", func(w http.ResponseWriter, r *http.Request) any {
+		return f.permissions
+	})
+	handle("PUT /repos/{owner}/{repo}/actions/permissions/workflow", func(w http.ResponseWriter, r *http.Request) any {
+		decode(r, f.permissions)
+		return f.permissions
+	})
+
+	handle("GET /repos/{owner}/{repo}/labels", func(w http.ResponseWriter, r *http.Request) any {
+		labels := []*github.Label{}
+		for _, label := range f.labels {
+			labels = append(labels, label)
+		}
+		return labels
+	})
+	handle("POST /repos/{owner}/{repo}/labels", func(w http.ResponseWriter, r *http.Request) any {
+		label := &github.Label{}
+		decode(r, label)
+		f.labels[label.GetName()] = label
+		w.WriteHeader(http.StatusCreated)
+		return label
+	})
+	handle("PATCH /repos/{owner}/{repo}/labels/{name}", func(w http.ResponseWriter, r *http.Request) any {
+		label, ok := f.labels[r.PathValue("name")]
+		if !ok {
+			return notFound(w)
+		}
+		decode(r, label)
+		return label
+	})
+	handle("DELETE /repos/{owner}/{repo}/labels/{name}", func(w http.ResponseWriter, r *http.Request) any {
+		delete(f.labels, r.PathValue("name"))
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+
+	handle("GET /repos/{owner}/{repo}/actions/secrets", func(w http.ResponseWriter, r *http.Request) any {
+		secrets := &github.Secrets{}
+		for name := range f.secrets {
+			secrets.Secrets = append(secrets.Secrets, &github.Secret{Name: name})
+		}
+		secrets.TotalCount = len(secrets.Secrets)
+		return secrets
+	})
+	handle("GET /repos/{owner}/{repo}/actions/organization-secrets", func(w http.ResponseWriter, r *http.Request) any {
+		secrets := &github.Secrets{}
+		for _, name := range f.orgSecrets {
+			secrets.Secrets = append(secrets.Secrets, &github.Secret{Name: name})
+		}
+		secrets.TotalCount = len(secrets.Secrets)
+		return secrets
+	})
+	publicKey := func(w http.ResponseWriter, r *http.Request) any {
+		return &github.PublicKey{
+			KeyID: github.Ptr("key"),
+			Key:   github.Ptr(base64.StdEncoding.EncodeToString(f.publicKey[:])),
+		}
+	}
+	touch := func(r *http.Request) {
+		f.updated[r.URL.Path] = github.Timestamp{Time: time.Unix(int64(len(f.requests)), 0).UTC()}
+	}
+	putScopedSecret := func(w http.ResponseWriter, r *http.Request) any {
+		secret := &github.EncryptedSecret{}
+		decode(r, secret)
+		f.scopedSecrets[r.URL.Path] = secret
+		touch(r)
+		w.WriteHeader(http.StatusCreated)
+		return nil
+	}
+	getScopedSecret := func(w http.ResponseWriter, r *http.Request) any {
+		if _, ok := f.scopedSecrets[r.URL.Path]; !ok {
+			return notFound(w)
+		}
+		return &github.Secret{Name: r.PathValue("name"), UpdatedAt: f.updated[r.URL.Path]}
+	}
+
+	handle("GET /repos/{owner}/{repo}/environments/{environment}", func(w http.ResponseWriter, r *http.Request) any {
+		if !f.environments[r.PathValue("environment")] {
+			return notFound(w)
+		}
+		return &github.Environment{Name: github.Ptr(r.PathValue("environment"))}
+	})
+	handle("PUT /repos/{owner}/{repo}/environments/{environment}", func(w http.ResponseWriter, r *http.Request) any {
+		f.environments[r.PathValue("environment")] = true
+		return &github.Environment{Name: github.Ptr(r.PathValue("environment"))}
+	})
+	handle("GET /repositories/{id}/environments/{environment}/secrets/public-key", publicKey)
+	handle("GET /repositories/{id}/environments/{environment}/secrets/{name}", getScopedSecret)
+	handle("PUT /repositories/{id}/environments/{environment}/secrets/{name}", putScopedSecret)
+	handle("GET /orgs/{org}/actions/secrets/public-key", publicKey)
+	handle("GET /orgs/{org}/actions/secrets/{name}", getScopedSecret)
+	handle("PUT /orgs/{org}/actions/secrets/{name}", putScopedSecret)
+	handle("GET /repos/{owner}/{repo}/dependabot/secrets/public-key", publicKey)
+	handle("GET /repos/{owner}/{repo}/dependabot/secrets/{name}", getScopedSecret)
+	handle("PUT /repos/{owner}/{repo}/dependabot/secrets/{name}", putScopedSecret)
+	handle("GET /orgs/{org}/dependabot/secrets/public-key", publicKey)
+	handle("GET /orgs/{org}/dependabot/secrets/{name}", getScopedSecret)
+	handle("PUT /orgs/{org}/dependabot/secrets/{name}", putScopedSecret)
+
+	handle("GET /repos/{owner}/{repo}/actions/secrets/public-key", publicKey)
+	handle("GET /repos/{owner}/{repo}/actions/secrets/{name}", func(w http.ResponseWriter, r *http.Request) any {
+		if _, ok := f.secrets[r.PathValue("name")]; !ok {
+			return notFound(w)
+		}
+		return &github.Secret{Name: r.PathValue("name"), UpdatedAt: f.updated[r.URL.Path]}
+	})
+	handle("PUT /repos/{owner}/{repo}/actions/secrets/{name}", func(w http.ResponseWriter, r *http.Request) any {
+		secret := &github.EncryptedSecret{}
+		decode(r, secret)
+		f.secrets[r.PathValue("name")] = secret
+		touch(r)
+		w.WriteHeader(http.StatusCreated)
+		return nil
+	})
+
+	listVariables := func(prefix string) any {
+		variables := &github.ActionsVariables{}
+		for key, variable := range f.variables {
+			if strings.HasPrefix(key, prefix) && !strings.Contains(strings.TrimPrefix(key, prefix), "/") {
+				variables.Variables = append(variables.Variables, variable)
+			}
+		}
+		variables.TotalCount = len(variables.Variables)
+		return variables
+	}
+	createVariable := func(w http.ResponseWriter, r *http.Request, prefix string) any {
+		variable := &github.ActionsVariable{}
+		decode(r, variable)
+		f.variables[prefix+variable.Name] = variable
+		w.WriteHeader(http.StatusCreated)
+		return nil
+	}
+	updateVariable := func(w http.ResponseWriter, r *http.Request, prefix string) any {
+		if _, ok := f.variables[prefix+r.PathValue("name")]; !ok {
+			return notFound(w)
+		}
+		variable := &github.ActionsVariable{}
+		decode(r, variable)
+		f.variables[prefix+r.PathValue("name")] = variable
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+
+	handle("GET /repos/{owner}/{repo}/actions/variables", func(w http.ResponseWriter, r *http.Request) any {
+		return listVariables("repo/")
+	})
+	handle("POST /repos/{owner}/{repo}/actions/variables", func(w http.ResponseWriter, r *http.Request) any {
+		return createVariable(w, r, "repo/")
+	})
+	handle("PATCH /repos/{owner}/{repo}/actions/variables/{name}", func(w http.ResponseWriter, r *http.Request) any {
+		return updateVariable(w, r, "repo/")
+	})
+	handle("GET /repos/{owner}/{repo}/environments/{environment}/variables", func(w http.ResponseWriter, r *http.Request) any {
+		if !f.environments[r.PathValue("environment")] {
+			return notFound(w)
+		}
+		return listVariables("environment/" + r.PathValue("environment") + "/")
+	})
+	handle("POST /repos/{owner}/{repo}/environments/{environment}/variables", func(w http.ResponseWriter, r *http.Request) any {
+		if !f.environments[r.PathValue("environment")] {
+			return notFound(w)
+		}
+		return createVariable(w, r, "environment/"+r.PathValue("environment")+"/")
+	})
+	handle("PATCH /repos/{owner}/{repo}/environments/{environment}/variables/{name}", func(w http.ResponseWriter, r *http.Request) any {
+		return updateVariable(w, r, "environment/"+r.PathValue("environment")+"/")
+	})
+	handle("GET /orgs/{org}/actions/variables/{name}", func(w http.ResponseWriter, r *http.Request) any {
+		variable, ok := f.variables["org/"+r.PathValue("name")]
+		if !ok {
+			return notFound(w)
+		}
+		return variable
+	})
+	handle("POST /orgs/{org}/actions/variables", func(w http.ResponseWriter, r *http.Request) any {
+		return createVariable(w, r, "org/")
+	})
+	handle("PATCH /orgs/{org}/actions/variables/{name}", func(w http.ResponseWriter, r *http.Request) any {
+		return updateVariable(w, r, "org/")
+	})
+
+	return mux
+}
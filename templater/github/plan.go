@@ -0,0 +1,221 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+)
+
+// Action is the kind of change needed to reconcile a resource.
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+func (a Action) symbol() string {
+	switch a {
+	case ActionCreate:
+		return "+"
+	case ActionDelete:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+// FieldChange is a single field whose current value on GitHub differs
+// from the desired one, both values are JSON encoded.
+type FieldChange struct {
+	Name string
+	Old  string
+	New  string
+}
+
+// Change is a resource that needs to be created, updated or deleted to
+// bring GitHub in line with the desired state.
+type Change struct {
+	Action   Action
+	Resource string
+	Fields   []FieldChange
+
+	apply func(ctx context.Context) error
+}
+
+// Apply executes the change against GitHub.
+func (c Change) Apply(ctx context.Context) error {
+	if err := c.apply(ctx); err != nil {
+		return fmt.Errorf("%s %s: %w", c.Action, c.Resource, err)
+	}
+	return nil
+}
+
+// Plan is the ordered set of changes needed to reconcile a repository.
+type Plan struct {
+	Changes []Change
+}
+
+// Empty returns whether the repository is already up to date.
+func (p Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Apply executes every change of the plan in order, stopping at the
+// first failure. Failures are returned as an *ApplyError tracking which
+// changes were completed, planning again picks up the remaining ones.
+func (p Plan) Apply(ctx context.Context) error {
+	for i, change := range p.Changes {
+		if err := change.Apply(ctx); err != nil {
+			return &ApplyError{
+				Applied:   p.Changes[:i],
+				Remaining: p.Changes[i:],
+				Err:       err,
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyError is returned when a plan is only partially applied.
+type ApplyError struct {
+	Applied   []Change
+	Remaining []Change
+	Err       error
+}
+
+func (e *ApplyError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ApplyError) Unwrap() error {
+	return e.Err
+}
+
+// CreatedRepository returns whether the repository itself was created
+// before the failure, meaning it's safe to roll back by deleting it.
+func (e *ApplyError) CreatedRepository() bool {
+	for _, change := range e.Applied {
+		if change.Action == ActionCreate && change.Resource == "repository" {
+			return true
+		}
+	}
+	return false
+}
+
+// Write renders the plan as a human readable diff.
+func (p Plan) Write(w io.Writer) error {
+	if p.Empty() {
+		_, err := fmt.Fprintln(w, "No changes. GitHub matches the configuration.")
+		return err
+	}
+
+	creates, updates, deletes := 0, 0, 0
+	for _, change := range p.Changes {
+		switch change.Action {
+		case ActionCreate:
+			creates++
+		case ActionUpdate:
+			updates++
+		case ActionDelete:
+			deletes++
+		}
+
+		if _, err := fmt.Fprintf(w, "  %s %s %s\n", change.Action.symbol(), change.Action, change.Resource); err != nil {
+			return err
+		}
+		for _, field := range change.Fields {
+			var err error
+			if change.Action == ActionCreate {
+				_, err = fmt.Fprintf(w, "      + %s = %s\n", field.Name, field.New)
+			} else {
+				_, err = fmt.Fprintf(w, "      ~ %s = %s -> %s\n", field.Name, valueOrNull(field.Old), valueOrNull(field.New))
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\nPlan: %d to create, %d to update, %d to delete.\n", creates, updates, deletes)
+	return err
+}
+
+func valueOrNull(value string) string {
+	if value == "" {
+		return "null"
+	}
+	return value
+}
+
+// diffFields compares the JSON representations of current and desired,
+// returning every field that differs. Only the given fields are compared,
+// or all fields set on desired if none are given.
+func diffFields(current, desired any, fields ...string) ([]FieldChange, error) {
+	currentFields, err := jsonFields(current)
+	if err != nil {
+		return nil, err
+	}
+	desiredFields, err := jsonFields(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) == 0 {
+		for field := range desiredFields {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+	}
+
+	changes := []FieldChange{}
+	for _, field := range fields {
+		if reflect.DeepEqual(currentFields[field].value, desiredFields[field].value) {
+			continue
+		}
+		changes = append(changes, FieldChange{
+			Name: field,
+			Old:  string(currentFields[field].raw),
+			New:  string(desiredFields[field].raw),
+		})
+	}
+	return changes, nil
+}
+
+type jsonField struct {
+	raw   json.RawMessage
+	value any
+}
+
+func jsonFields(v any) (map[string]jsonField, error) {
+	fields := map[string]jsonField{}
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil() {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	for name, message := range raw {
+		var value any
+		if err := json.Unmarshal(message, &value); err != nil {
+			return nil, err
+		}
+		fields[name] = jsonField{raw: message, value: value}
+	}
+	return fields, nil
+}
@@ -0,0 +1,226 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/go-github/v69/github"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+)
+
+// rulesetFields are the ruleset fields compared when reconciling, everything
+// else is either metadata or managed by GitHub.
+var rulesetFields = []string{"target", "enforcement", "bypass_actors", "conditions", "rules"}
+
+var (
+	rulesetTargets      = []string{string(github.RulesetTargetBranch), string(github.RulesetTargetTag)}
+	rulesetEnforcements = []string{
+		string(github.RulesetEnforcementActive), string(github.RulesetEnforcementEvaluate), string(github.RulesetEnforcementDisabled),
+	}
+	mergeMethods = []string{
+		string(github.MergeMethodMerge), string(github.MergeMethodRebase), string(github.MergeMethodSquash),
+	}
+	bypassActorTypes = []string{
+		string(github.BypassActorTypeIntegration), string(github.BypassActorTypeOrganizationAdmin),
+		string(github.BypassActorTypeRepositoryRole), string(github.BypassActorTypeTeam), string(github.BypassActorTypeDeployKey),
+	}
+	bypassModes = []string{string(github.BypassModeAlways), string(github.BypassModePullRequest)}
+)
+
+// desiredRuleset builds a ruleset from its configuration. Branch rulesets
+// require pull requests and block deletions and force pushes, tag rulesets
+// block deleting, moving and force pushing matching tags.
+func desiredRuleset(info config.RulesetInfo) (github.RepositoryRuleset, error) {
+	if info.Name == "" {
+		return github.RepositoryRuleset{}, errors.New("name is required")
+	}
+
+	target := cmp.Or(info.Target, string(github.RulesetTargetBranch))
+	if !slices.Contains(rulesetTargets, target) {
+		return github.RepositoryRuleset{}, fmt.Errorf("unsupported target %q, must be one of %v", target, rulesetTargets)
+	}
+
+	enforcement := cmp.Or(info.Enforcement, string(github.RulesetEnforcementActive))
+	if !slices.Contains(rulesetEnforcements, enforcement) {
+		return github.RepositoryRuleset{}, fmt.Errorf("unsupported enforcement %q, must be one of %v", enforcement, rulesetEnforcements)
+	}
+
+	bypassActors, err := desiredBypassActors(info.BypassActors)
+	if err != nil {
+		return github.RepositoryRuleset{}, err
+	}
+
+	rules := &github.RepositoryRulesetRules{
+		Deletion:       &github.EmptyRuleParameters{},
+		NonFastForward: &github.EmptyRuleParameters{},
+	}
+	if info.RequireSignedCommits {
+		rules.RequiredSignatures = &github.EmptyRuleParameters{}
+	}
+
+	include := info.Include
+	switch github.RulesetTarget(target) {
+	case github.RulesetTargetTag:
+		if len(info.MergeMethods) != 0 || info.RequiredApprovals != nil || info.RequireCodeOwnerReview || info.DismissStaleReviews || info.RequireLinearHistory {
+			return github.RepositoryRuleset{}, errors.New("pull request and linear history settings are only supported on branch rulesets")
+		}
+		if len(include) == 0 {
+			include = []string{"refs/tags/v*"}
+		}
+		rules.Update = &github.UpdateRuleParameters{}
+	default:
+		if len(include) == 0 {
+			include = []string{"~DEFAULT_BRANCH", "refs/heads/v**"}
+		}
+		pullRequest, err := desiredPullRequestRule(info)
+		if err != nil {
+			return github.RepositoryRuleset{}, err
+		}
+		rules.PullRequest = pullRequest
+		if info.RequireLinearHistory {
+			rules.RequiredLinearHistory = &github.EmptyRuleParameters{}
+		}
+	}
+
+	return normalizeRuleset(github.RepositoryRuleset{
+		Name:         info.Name,
+		Target:       github.Ptr(github.RulesetTarget(target)),
+		Enforcement:  github.RulesetEnforcement(enforcement),
+		BypassActors: bypassActors,
+		Conditions: &github.RepositoryRulesetConditions{
+			RefName: &github.RepositoryRulesetRefConditionParameters{
+				Include: include,
+				Exclude: info.Exclude,
+			},
+		},
+		Rules: rules,
+	}), nil
+}
+
+func desiredPullRequestRule(info config.RulesetInfo) (*github.PullRequestRuleParameters, error) {
+	methods := []github.MergeMethod{}
+	for _, method := range info.MergeMethods {
+		if !slices.Contains(mergeMethods, method) {
+			return nil, fmt.Errorf("unsupported merge method %q, must be one of %v", method, mergeMethods)
+		}
+		methods = append(methods, github.MergeMethod(method))
+	}
+	if len(methods) == 0 {
+		methods = []github.MergeMethod{
+			github.MergeMethodMerge, github.MergeMethodRebase, github.MergeMethodSquash,
+		}
+	}
+
+	approvals := 1
+	if info.RequiredApprovals != nil {
+		approvals = *info.RequiredApprovals
+	}
+	if approvals < 0 || approvals > 10 {
+		return nil, fmt.Errorf("required approvals must be between 0 and 10, got %d", approvals)
+	}
+
+	return &github.PullRequestRuleParameters{
+		AllowedMergeMethods:          methods,
+		DismissStaleReviewsOnPush:    info.DismissStaleReviews,
+		RequireCodeOwnerReview:       info.RequireCodeOwnerReview,
+		RequiredApprovingReviewCount: approvals,
+	}, nil
+}
+
+func desiredBypassActors(actors []config.BypassActorInfo) ([]*github.BypassActor, error) {
+	bypassActors := []*github.BypassActor{}
+	for _, actor := range actors {
+		if !slices.Contains(bypassActorTypes, actor.ActorType) {
+			return nil, fmt.Errorf("unsupported bypass actor type %q, must be one of %v", actor.ActorType, bypassActorTypes)
+		}
+
+		mode := cmp.Or(actor.Mode, string(github.BypassModeAlways))
+		if !slices.Contains(bypassModes, mode) {
+			return nil, fmt.Errorf("unsupported bypass mode %q, must be one of %v", mode, bypassModes)
+		}
+
+		bypassActor := &github.BypassActor{
+			ActorType:  github.Ptr(github.BypassActorType(actor.ActorType)),
+			BypassMode: github.Ptr(github.BypassMode(mode)),
+		}
+		switch github.BypassActorType(actor.ActorType) {
+		case github.BypassActorTypeOrganizationAdmin, github.BypassActorTypeDeployKey:
+			// GitHub ignores the actor id of these actor types
+		default:
+			if actor.ActorID == 0 {
+				return nil, fmt.Errorf("bypass actor of type %q requires an actor id", actor.ActorType)
+			}
+			bypassActor.ActorID = github.Ptr(actor.ActorID)
+		}
+		bypassActors = append(bypassActors, bypassActor)
+	}
+	return bypassActors, nil
+}
+
+// normalizeRuleset makes rulesets comparable regardless of whether they
+// were built locally or returned by GitHub.
+func normalizeRuleset(ruleset github.RepositoryRuleset) github.RepositoryRuleset {
+	bypassActors := []*github.BypassActor{}
+	for _, actor := range ruleset.BypassActors {
+		actor := *actor
+		switch bypassActorType(&actor) {
+		case github.BypassActorTypeOrganizationAdmin, github.BypassActorTypeDeployKey:
+			actor.ActorID = nil
+		}
+		bypassActors = append(bypassActors, &actor)
+	}
+	slices.SortFunc(bypassActors, func(a, b *github.BypassActor) int {
+		return cmp.Or(
+			cmp.Compare(bypassActorType(a), bypassActorType(b)),
+			cmp.Compare(a.GetActorID(), b.GetActorID()),
+		)
+	})
+	ruleset.BypassActors = bypassActors
+
+	if ruleset.Conditions != nil && ruleset.Conditions.RefName != nil {
+		refs := *ruleset.Conditions.RefName
+		refs.Include = sortedCopy(refs.Include)
+		refs.Exclude = sortedCopy(refs.Exclude)
+		ruleset.Conditions = &github.RepositoryRulesetConditions{RefName: &refs}
+	}
+	if ruleset.Rules != nil {
+		rules := *ruleset.Rules
+		if rules.PullRequest != nil {
+			pullRequest := *rules.PullRequest
+			pullRequest.AllowedMergeMethods = sortedCopy(pullRequest.AllowedMergeMethods)
+			rules.PullRequest = &pullRequest
+		}
+		if rules.RequiredStatusChecks != nil {
+			statusChecks := *rules.RequiredStatusChecks
+			if statusChecks.DoNotEnforceOnCreate == nil {
+				statusChecks.DoNotEnforceOnCreate = github.Ptr(false)
+			}
+			statusChecks.RequiredStatusChecks = slices.Clone(statusChecks.RequiredStatusChecks)
+			slices.SortFunc(statusChecks.RequiredStatusChecks, func(a, b *github.RuleStatusCheck) int {
+				return cmp.Compare(a.Context, b.Context)
+			})
+			rules.RequiredStatusChecks = &statusChecks
+		}
+		ruleset.Rules = &rules
+	}
+	return ruleset
+}
+
+func bypassActorType(actor *github.BypassActor) github.BypassActorType {
+	if actor.ActorType == nil {
+		return ""
+	}
+	return *actor.ActorType
+}
+
+func sortedCopy[T ~string](values []T) []T {
+	sorted := append([]T{}, values...)
+	slices.Sort(sorted)
+	return sorted
+}
@@ -0,0 +1,128 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+)
+
+func TestDesiredRuleset(t *testing.T) {
+	approvals := 2
+
+	for name, tt := range map[string]struct {
+		info     config.RulesetInfo
+		expected string
+		err      string
+	}{
+		"default": {
+			info:     config.RulesetInfo{Name: "Require PR"},
+			expected: `{"name":"Require PR","target":"branch","source":"","enforcement":"active","conditions":{"ref_name":{"include":["refs/heads/v**","~DEFAULT_BRANCH"],"exclude":[]}},"rules":[{"type":"deletion"},{"type":"pull_request","parameters":{"allowed_merge_methods":["merge","rebase","squash"],"dismiss_stale_reviews_on_push":false,"require_code_owner_review":false,"require_last_push_approval":false,"required_approving_review_count":1,"required_review_thread_resolution":false}},{"type":"non_fast_forward"}]}`,
+		},
+		"branch": {
+			info: config.RulesetInfo{
+				Name:                   "Main",
+				Enforcement:            "evaluate",
+				Include:                []string{"~DEFAULT_BRANCH"},
+				MergeMethods:           []string{"squash"},
+				RequiredApprovals:      &approvals,
+				RequireCodeOwnerReview: true,
+				DismissStaleReviews:    true,
+				RequireLinearHistory:   true,
+				RequireSignedCommits:   true,
+				BypassActors: []config.BypassActorInfo{
+					{ActorType: "Team", ActorID: 5, Mode: "pull_request"},
+					{ActorType: "OrganizationAdmin"},
+				},
+			},
+			expected: `{"name":"Main","target":"branch","source":"","enforcement":"evaluate","bypass_actors":[{"actor_type":"OrganizationAdmin","bypass_mode":"always"},{"actor_id":5,"actor_type":"Team","bypass_mode":"pull_request"}],"conditions":{"ref_name":{"include":["~DEFAULT_BRANCH"],"exclude":[]}},"rules":[{"type":"deletion"},{"type":"required_linear_history"},{"type":"required_signatures"},{"type":"pull_request","parameters":{"allowed_merge_methods":["squash"],"dismiss_stale_reviews_on_push":true,"require_code_owner_review":true,"require_last_push_approval":false,"required_approving_review_count":2,"required_review_thread_resolution":false}},{"type":"non_fast_forward"}]}`,
+		},
+		"tag": {
+			info:     config.RulesetInfo{Name: "Release tags", Target: "tag"},
+			expected: `{"name":"Release tags","target":"tag","source":"","enforcement":"active","conditions":{"ref_name":{"include":["refs/tags/v*"],"exclude":[]}},"rules":[{"type":"update"},{"type":"deletion"},{"type":"non_fast_forward"}]}`,
+		},
+		"tag with pull request settings": {
+			info: config.RulesetInfo{Name: "Release tags", Target: "tag", RequiredApprovals: &approvals},
+			err:  "pull request and linear history settings are only supported on branch rulesets",
+		},
+		"unsupported target": {
+			info: config.RulesetInfo{Name: "Push", Target: "push"},
+			err:  `unsupported target "push", must be one of [branch tag]`,
+		},
+		"unsupported merge method": {
+			info: config.RulesetInfo{Name: "Main", MergeMethods: []string{"fast-forward"}},
+			err:  `unsupported merge method "fast-forward", must be one of [merge rebase squash]`,
+		},
+		"bypass actor without id": {
+			info: config.RulesetInfo{Name: "Main", BypassActors: []config.BypassActorInfo{{ActorType: "Team"}}},
+			err:  `bypass actor of type "Team" requires an actor id`,
+		},
+		"missing name": {
+			err: "name is required",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ruleset, err := desiredRuleset(tt.info)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := json.Marshal(ruleset)
+			require.NoError(t, err)
+			require.JSONEq(t, tt.expected, string(data))
+		})
+	}
+}
+
+func TestPlanSettingsRulesets(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGithub(t)
+	client := fake.client(t)
+
+	settings, err := DesiredSettings(config.ConfigFile{
+		GithubInfo: config.GithubInfo{
+			Organization: "org",
+			Repository:   "repo",
+			Rulesets: []config.RulesetInfo{
+				{Name: "Require PR", BypassActors: []config.BypassActorInfo{{ActorType: "RepositoryRole", ActorID: 5}}},
+				{Name: "Release tags", Target: "tag"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	settings.Secrets = nil
+	settings.Rulesets = append(settings.Rulesets, StatusCheckRulesets(config.ConfigFile{}, []WorkflowCheck{{Name: "Build and test"}})...)
+
+	_, err = client.ApplySettings(ctx, "org", "repo", settings)
+	require.NoError(t, err)
+	require.Len(t, fake.rulesets, 3)
+
+	plan, err := client.PlanSettings(ctx, "org", "repo", settings)
+	require.NoError(t, err)
+	require.True(t, plan.Empty())
+
+	settings.Rulesets[1].Conditions.RefName.Include = []string{"refs/tags/release-*"}
+	plan, err = client.PlanSettings(ctx, "org", "repo", settings)
+	require.NoError(t, err)
+	require.Equal(t, []string{`update ruleset "Release tags"`}, resources(plan))
+
+	// no longer requiring status checks removes the managed ruleset
+	settings.Rulesets = settings.Rulesets[:2]
+	plan, err = client.PlanSettings(ctx, "org", "repo", settings)
+	require.NoError(t, err)
+	require.Equal(t, []string{`update ruleset "Release tags"`, `delete ruleset "Required status checks"`}, resources(plan))
+
+	require.NoError(t, plan.Apply(ctx))
+	require.Len(t, fake.rulesets, 2)
+	plan, err = client.PlanSettings(ctx, "org", "repo", settings)
+	require.NoError(t, err)
+	require.True(t, plan.Empty())
+}
@@ -0,0 +1,334 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v69/github"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+	"github.com/andrewstucki/actions-testing/templater/secrets"
+)
+
+// SetSecret encrypts and sets a declared secret at its scope: on the
+// repository, on one of its environments, creating the environment if it
+// doesn't exist, or on the organization with the declared visibility.
+// Organization secrets with selected visibility default to only being
+// shared with the repository. Dependabot secrets are set through the
+// Dependabot API instead of the Actions one.
+func (c *Client) SetSecret(ctx context.Context, secret config.SecretInfo, value string) error {
+	if c.organization == "" || c.repo == "" {
+		return errors.New("must set repository before setting encrypted secret")
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	switch {
+	case secret.Scope == secrets.ScopeEnvironment:
+		return c.setEnvironmentSecret(ctx, secret.Environment, secret.Name, value)
+	case secret.Scope == secrets.ScopeOrganization:
+		return c.setOrganizationSecret(ctx, secret, value)
+	case secret.Dependabot:
+		key, err := c.publicKey("dependabot", func() (*github.PublicKey, *github.Response, error) {
+			return c.Client.Dependabot.GetRepoPublicKey(ctx, c.organization, c.repo)
+		})
+		if err != nil {
+			return err
+		}
+		encrypted, err := encrypt(key, value)
+		if err != nil {
+			return err
+		}
+		_, err = c.Client.Dependabot.CreateOrUpdateRepoSecret(ctx, c.organization, c.repo, &github.DependabotEncryptedSecret{
+			Name:           secret.Name,
+			EncryptedValue: encrypted,
+			KeyID:          key.GetKeyID(),
+		})
+		return err
+	default:
+		return c.SetEncryptedSecret(ctx, secret.Name, value)
+	}
+}
+
+// GetSecret returns a declared secret's metadata, or nil if it isn't set.
+func (c *Client) GetSecret(ctx context.Context, secret config.SecretInfo) (*github.Secret, error) {
+	var current *github.Secret
+	var err error
+
+	switch {
+	case secret.Scope == secrets.ScopeEnvironment:
+		repositoryID, idErr := c.currentRepositoryID(ctx)
+		if idErr != nil {
+			return nil, idErr
+		}
+		current, _, err = c.Client.Actions.GetEnvSecret(ctx, int(repositoryID), secret.Environment, secret.Name)
+	case secret.Scope == secrets.ScopeOrganization && secret.Dependabot:
+		current, _, err = c.Client.Dependabot.GetOrgSecret(ctx, c.organization, secret.Name)
+	case secret.Scope == secrets.ScopeOrganization:
+		current, _, err = c.Client.Actions.GetOrgSecret(ctx, c.organization, secret.Name)
+	case secret.Dependabot:
+		current, _, err = c.Client.Dependabot.GetRepoSecret(ctx, c.organization, c.repo, secret.Name)
+	default:
+		current, _, err = c.Client.Actions.GetRepoSecret(ctx, c.organization, c.repo, secret.Name)
+	}
+	if isNotFound(err) {
+		return nil, nil
+	}
+	return current, err
+}
+
+// SyncSecrets sets the given secrets with at most concurrency requests in
+// flight, continuing past failures. Secrets whose value matches the one
+// recorded in state are skipped unless force is set, a nil state syncs
+// everything. Results are returned in the order of the given values.
+func (c *Client) SyncSecrets(ctx context.Context, values []secrets.Value, state *secrets.State, force bool, concurrency int) []secrets.Result {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]secrets.Result, len(values))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, value := range values {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			status, err := c.syncSecret(ctx, value, state, force)
+			results[i] = secrets.Result{SecretInfo: value.SecretInfo, Status: status, Err: err}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (c *Client) syncSecret(ctx context.Context, value secrets.Value, state *secrets.State, force bool) (secrets.Status, error) {
+	key := secrets.StateKey(c.organization, c.repo, value.SecretInfo)
+
+	if state != nil && !force {
+		current, err := c.GetSecret(ctx, value.SecretInfo)
+		if err != nil {
+			return secrets.StatusFailed, err
+		}
+		if current != nil && state.Unchanged(key, value.Value, current.UpdatedAt.Time) {
+			return secrets.StatusUnchanged, nil
+		}
+	}
+
+	if err := c.SetSecret(ctx, value.SecretInfo, value.Value); err != nil {
+		return secrets.StatusFailed, err
+	}
+
+	if state != nil {
+		// the update time is only used to skip the secret next time, so
+		// failing to get it just means it's synced again
+		if current, err := c.GetSecret(ctx, value.SecretInfo); err == nil && current != nil {
+			state.Record(key, value.Value, current.UpdatedAt.Time)
+		}
+	}
+	return secrets.StatusUpdated, nil
+}
+
+func (c *Client) setEnvironmentSecret(ctx context.Context, environment, name, value string) error {
+	if err := c.ensureEnvironment(ctx, environment); err != nil {
+		return err
+	}
+
+	repositoryID, err := c.currentRepositoryID(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, err := c.publicKey("environment/"+environment, func() (*github.PublicKey, *github.Response, error) {
+		return c.Client.Actions.GetEnvPublicKey(ctx, int(repositoryID), environment)
+	})
+	if err != nil {
+		return err
+	}
+	encrypted, err := encrypt(key, value)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Client.Actions.CreateOrUpdateEnvSecret(ctx, int(repositoryID), environment, &github.EncryptedSecret{
+		Name:           name,
+		EncryptedValue: encrypted,
+		KeyID:          key.GetKeyID(),
+	})
+	return err
+}
+
+// ensureEnvironment creates the environment unless it already exists,
+// existing environments are left alone to keep their protection rules.
+func (c *Client) ensureEnvironment(ctx context.Context, environment string) error {
+	c.cache.Lock()
+	defer c.cache.Unlock()
+
+	if c.environments[environment] {
+		return nil
+	}
+
+	_, _, err := c.Client.Repositories.GetEnvironment(ctx, c.organization, c.repo, environment)
+	if isNotFound(err) {
+		_, _, err = c.Client.Repositories.CreateUpdateEnvironment(ctx, c.organization, c.repo, environment, nil)
+		if err != nil {
+			return fmt.Errorf("creating environment %q: %w", environment, err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("getting environment %q: %w", environment, err)
+	}
+
+	if c.environments == nil {
+		c.environments = map[string]bool{}
+	}
+	c.environments[environment] = true
+	return nil
+}
+
+func (c *Client) setOrganizationSecret(ctx context.Context, secret config.SecretInfo, value string) error {
+	visibility := secret.Visibility
+	if visibility == "" {
+		visibility = secrets.VisibilitySelected
+	}
+
+	var selected []int64
+	if visibility == secrets.VisibilitySelected {
+		var err error
+		if selected, err = c.selectedRepositoryIDs(ctx, secret.Repositories); err != nil {
+			return err
+		}
+	}
+
+	if secret.Dependabot {
+		key, err := c.publicKey("organization/dependabot", func() (*github.PublicKey, *github.Response, error) {
+			return c.Client.Dependabot.GetOrgPublicKey(ctx, c.organization)
+		})
+		if err != nil {
+			return err
+		}
+		encrypted, err := encrypt(key, value)
+		if err != nil {
+			return err
+		}
+		_, err = c.Client.Dependabot.CreateOrUpdateOrgSecret(ctx, c.organization, &github.DependabotEncryptedSecret{
+			Name:                  secret.Name,
+			EncryptedValue:        encrypted,
+			KeyID:                 key.GetKeyID(),
+			Visibility:            visibility,
+			SelectedRepositoryIDs: selected,
+		})
+		return err
+	}
+
+	key, err := c.publicKey("organization", func() (*github.PublicKey, *github.Response, error) {
+		return c.Client.Actions.GetOrgPublicKey(ctx, c.organization)
+	})
+	if err != nil {
+		return err
+	}
+	encrypted, err := encrypt(key, value)
+	if err != nil {
+		return err
+	}
+	_, err = c.Client.Actions.CreateOrUpdateOrgSecret(ctx, c.organization, &github.EncryptedSecret{
+		Name:                  secret.Name,
+		EncryptedValue:        encrypted,
+		KeyID:                 key.GetKeyID(),
+		Visibility:            visibility,
+		SelectedRepositoryIDs: selected,
+	})
+	return err
+}
+
+// selectedRepositoryIDs returns the ids of the given organization
+// repositories, or of the current repository if none are given.
+func (c *Client) selectedRepositoryIDs(ctx context.Context, repositories []string) ([]int64, error) {
+	if len(repositories) == 0 {
+		id, err := c.currentRepositoryID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []int64{id}, nil
+	}
+
+	ids := []int64{}
+	for _, name := range repositories {
+		repository, _, err := c.Client.Repositories.Get(ctx, c.organization, name)
+		if err != nil {
+			return nil, fmt.Errorf("getting repository %q: %w", name, err)
+		}
+		ids = append(ids, repository.GetID())
+	}
+	return ids, nil
+}
+
+func (c *Client) currentRepositoryID(ctx context.Context) (int64, error) {
+	c.cache.Lock()
+	defer c.cache.Unlock()
+
+	if c.repositoryID != 0 {
+		return c.repositoryID, nil
+	}
+
+	repository, _, err := c.Client.Repositories.Get(ctx, c.organization, c.repo)
+	if err != nil {
+		return 0, fmt.Errorf("getting repository: %w", err)
+	}
+	c.repositoryID = repository.GetID()
+	return c.repositoryID, nil
+}
+
+// ListSecrets returns the Actions secrets available to the repository,
+// both its own and the organization secrets shared with it.
+func (c *Client) ListSecrets(ctx context.Context, organization, repo string) ([]secrets.Existing, error) {
+	repository, err := listSecrets(ctx, func(options *github.ListOptions) (*github.Secrets, *github.Response, error) {
+		return c.Client.Actions.ListRepoSecrets(ctx, organization, repo, options)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing secrets: %w", err)
+	}
+	shared, err := listSecrets(ctx, func(options *github.ListOptions) (*github.Secrets, *github.Response, error) {
+		return c.Client.Actions.ListRepoOrgSecrets(ctx, organization, repo, options)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing organization secrets: %w", err)
+	}
+
+	existing := []secrets.Existing{}
+	for _, secret := range repository {
+		existing = append(existing, secrets.Existing{Name: secret.Name, Scope: secrets.ScopeRepository, UpdatedAt: secret.UpdatedAt.Time})
+	}
+	for _, secret := range shared {
+		existing = append(existing, secrets.Existing{Name: secret.Name, Scope: secrets.ScopeOrganization, UpdatedAt: secret.UpdatedAt.Time})
+	}
+	return existing, nil
+}
+
+// listSecrets pages through one of the secret listing endpoints.
+func listSecrets(ctx context.Context, list func(options *github.ListOptions) (*github.Secrets, *github.Response, error)) ([]*github.Secret, error) {
+	all := []*github.Secret{}
+	options := &github.ListOptions{PerPage: 100}
+	for {
+		page, response, err := list(options)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page.Secrets...)
+		if response.NextPage == 0 {
+			return all, nil
+		}
+		options.Page = response.NextPage
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/google/go-github/v69/github"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+	"github.com/andrewstucki/actions-testing/templater/secrets"
+)
+
+func TestListSecrets(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGithub(t)
+	client := fake.client(t)
+
+	fake.secrets["BOT_TOKEN"] = &github.EncryptedSecret{}
+	fake.orgSecrets = []string{"NPM_TOKEN"}
+
+	existing, err := client.ListSecrets(ctx, "org", "repo")
+	require.NoError(t, err)
+	require.Equal(t, []secrets.Existing{
+		{Name: "BOT_TOKEN", Scope: secrets.ScopeRepository},
+		{Name: "NPM_TOKEN", Scope: secrets.ScopeOrganization},
+	}, existing)
+}
+
+func TestSetSecret(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGithub(t)
+	fake.repository = &github.Repository{ID: github.Ptr(int64(7)), Name: github.Ptr("repo")}
+
+	client, err := fake.client(t).SetRepository(ctx, "org", "repo")
+	require.NoError(t, err)
+
+	for _, secret := range []config.SecretInfo{
+		{Name: "TOKEN", Scope: secrets.ScopeRepository},
+		{Name: "DEPLOY_KEY", Scope: secrets.ScopeEnvironment, Environment: "production"},
+		{Name: "DEPLOY_TOKEN", Scope: secrets.ScopeEnvironment, Environment: "production"},
+		{Name: "NPM_TOKEN", Scope: secrets.ScopeOrganization, Visibility: secrets.VisibilitySelected},
+		{Name: "SHARED_TOKEN", Scope: secrets.ScopeOrganization, Visibility: secrets.VisibilityPrivate},
+		{Name: "NPM_TOKEN", Scope: secrets.ScopeRepository, Dependabot: true},
+		{Name: "NPM_TOKEN", Scope: secrets.ScopeOrganization, Visibility: secrets.VisibilityAll, Dependabot: true},
+	} {
+		require.NoError(t, client.SetSecret(ctx, secret, "value"), secret.Name)
+	}
+
+	require.Contains(t, fake.secrets, "TOKEN")
+	require.Equal(t, map[string]bool{"production": true}, fake.environments)
+
+	paths := []string{}
+	for path, secret := range fake.scopedSecrets {
+		require.Equal(t, "key", secret.KeyID)
+		require.NotEmpty(t, secret.EncryptedValue)
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	require.Equal(t, []string{
+		"/orgs/org/actions/secrets/NPM_TOKEN",
+		"/orgs/org/actions/secrets/SHARED_TOKEN",
+		"/orgs/org/dependabot/secrets/NPM_TOKEN",
+		"/repos/org/repo/dependabot/secrets/NPM_TOKEN",
+		"/repositories/7/environments/production/secrets/DEPLOY_KEY",
+		"/repositories/7/environments/production/secrets/DEPLOY_TOKEN",
+	}, paths)
+
+	require.Equal(t, "selected", fake.scopedSecrets["/orgs/org/actions/secrets/NPM_TOKEN"].Visibility)
+	require.Equal(t, github.SelectedRepoIDs{7}, fake.scopedSecrets["/orgs/org/actions/secrets/NPM_TOKEN"].SelectedRepositoryIDs)
+	require.Equal(t, "private", fake.scopedSecrets["/orgs/org/actions/secrets/SHARED_TOKEN"].Visibility)
+	require.Empty(t, fake.scopedSecrets["/orgs/org/actions/secrets/SHARED_TOKEN"].SelectedRepositoryIDs)
+	require.Equal(t, "all", fake.scopedSecrets["/orgs/org/dependabot/secrets/NPM_TOKEN"].Visibility)
+
+	// every public key is only fetched once and the environment is only
+	// created once
+	counts := map[string]int{}
+	for _, request := range fake.requests {
+		counts[request]++
+	}
+	require.Equal(t, 1, counts["GET /repos/org/repo/actions/secrets/public-key"])
+	require.Equal(t, 1, counts["GET /repositories/7/environments/production/secrets/public-key"])
+	require.Equal(t, 1, counts["GET /orgs/org/actions/secrets/public-key"])
+	require.Equal(t, 1, counts["PUT /repos/org/repo/environments/production"])
+}
+
+func TestSyncSecrets(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGithub(t)
+	fake.repository = &github.Repository{ID: github.Ptr(int64(7)), Name: github.Ptr("repo")}
+	fake.failures["PUT /repositories/7/environments/production/secrets/DEPLOY_KEY"] = http.StatusForbidden
+
+	client, err := fake.client(t).SetRepository(ctx, "org", "repo")
+	require.NoError(t, err)
+
+	state, err := secrets.LoadState(filepath.Join(t.TempDir(), "secrets.json"))
+	require.NoError(t, err)
+
+	values := []secrets.Value{
+		{SecretInfo: config.SecretInfo{Name: "TOKEN", Scope: secrets.ScopeRepository}, Value: "token"},
+		{SecretInfo: config.SecretInfo{Name: "DEPLOY_KEY", Scope: secrets.ScopeEnvironment, Environment: "production"}, Value: "key"},
+		{SecretInfo: config.SecretInfo{Name: "NPM_TOKEN", Scope: secrets.ScopeOrganization, Visibility: secrets.VisibilityAll}, Value: "npm"},
+	}
+	statuses := func(results []secrets.Result) []secrets.Status {
+		statuses := []secrets.Status{}
+		for i, result := range results {
+			require.Equal(t, values[i].SecretInfo, result.SecretInfo)
+			statuses = append(statuses, result.Status)
+		}
+		return statuses
+	}
+
+	results := client.SyncSecrets(ctx, values, state, false, 2)
+	require.Equal(t, []secrets.Status{secrets.StatusUpdated, secrets.StatusFailed, secrets.StatusUpdated}, statuses(results))
+	require.ErrorContains(t, results[1].Err, "403")
+
+	results = client.SyncSecrets(ctx, values, state, false, 2)
+	require.Equal(t, []secrets.Status{secrets.StatusUnchanged, secrets.StatusFailed, secrets.StatusUnchanged}, statuses(results))
+
+	// changed values, secrets updated outside of templater and forced
+	// syncs are all set again
+	values[0].Value = "rotated"
+	fake.updated["/orgs/org/actions/secrets/NPM_TOKEN"] = github.Timestamp{}
+	results = client.SyncSecrets(ctx, values, state, false, 2)
+	require.Equal(t, []secrets.Status{secrets.StatusUpdated, secrets.StatusFailed, secrets.StatusUpdated}, statuses(results))
+
+	delete(fake.failures, "PUT /repositories/7/environments/production/secrets/DEPLOY_KEY")
+	results = client.SyncSecrets(ctx, values, state, true, 1)
+	require.Equal(t, []secrets.Status{secrets.StatusUpdated, secrets.StatusUpdated, secrets.StatusUpdated}, statuses(results))
+}
@@ -0,0 +1,529 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v69/github"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+	"github.com/andrewstucki/actions-testing/templater/secrets"
+)
+
+var (
+	labelColorPattern = regexp.MustCompile(`^[0-9a-f]{6}$`)
+
+	visibilities            = []string{"public", "private", "internal"}
+	squashMergeCommitTitles = []string{"PR_TITLE", "COMMIT_OR_PR_TITLE"}
+	workflowPermissions     = []string{"read", "write"}
+)
+
+// Settings is the desired state of a repository on GitHub.
+type Settings struct {
+	Repository          *github.Repository
+	WorkflowPermissions github.DefaultWorkflowPermissionRepository
+	Rulesets            []github.RepositoryRuleset
+	Topics              []string
+	Labels              []*github.Label
+	Secrets             []string
+
+	// PruneLabels deletes repository labels that aren't configured.
+	PruneLabels bool
+	// SecretValue is called when applying a plan to get the value of a
+	// secret that is missing from the repository.
+	SecretValue func(name string) (string, error)
+}
+
+// DesiredSettings builds the desired repository state from the configuration,
+// falling back to the defaults every templated repository starts with.
+func DesiredSettings(cfg config.ConfigFile) (Settings, error) {
+	info := cfg.GithubInfo
+
+	repository, err := desiredRepository(info.Settings)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	declared, err := secrets.Declared(cfg)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	settings := Settings{
+		Repository: repository,
+		WorkflowPermissions: github.DefaultWorkflowPermissionRepository{
+			DefaultWorkflowPermissions:   github.Ptr("write"),
+			CanApprovePullRequestReviews: boolOr(info.WorkflowPermissions.CanApprovePullRequestReviews, true),
+		},
+		Secrets: secrets.Names(declared, secrets.ScopeRepository),
+	}
+	if info.Settings.Topics != nil {
+		topics := []string{}
+		for _, topic := range info.Settings.Topics {
+			topics = append(topics, strings.ToLower(topic))
+		}
+		slices.Sort(topics)
+		settings.Topics = slices.Compact(topics)
+	}
+	if info.WorkflowPermissions.Default != "" {
+		if !slices.Contains(workflowPermissions, info.WorkflowPermissions.Default) {
+			return Settings{}, fmt.Errorf("unsupported default workflow permissions %q, must be one of %v", info.WorkflowPermissions.Default, workflowPermissions)
+		}
+		settings.WorkflowPermissions.DefaultWorkflowPermissions = github.Ptr(info.WorkflowPermissions.Default)
+	}
+
+	rulesets := info.Rulesets
+	if len(rulesets) == 0 {
+		rulesets = []config.RulesetInfo{{Name: "Require PR"}}
+	}
+	for _, info := range rulesets {
+		ruleset, err := desiredRuleset(info)
+		if err != nil {
+			return Settings{}, fmt.Errorf("ruleset %q: %w", info.Name, err)
+		}
+		settings.Rulesets = append(settings.Rulesets, ruleset)
+	}
+
+	// mirrors the labels rendered into .github/labels.yml
+	labels := []config.LabelInfo{}
+	for _, version := range cfg.Backports.Versions {
+		labels = append(labels, config.LabelInfo{Name: version, Color: "ededed"})
+	}
+	for _, info := range append(labels, config.WithDefaultLabels(cfg.Labels)...) {
+		label, err := desiredLabel(info)
+		if err != nil {
+			return Settings{}, fmt.Errorf("label %q: %w", info.Name, err)
+		}
+		settings.Labels = append(settings.Labels, label)
+	}
+
+	return settings, nil
+}
+
+func desiredLabel(info config.LabelInfo) (*github.Label, error) {
+	if info.Name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	color := strings.ToLower(strings.TrimPrefix(info.Color, "#"))
+	if !labelColorPattern.MatchString(color) {
+		return nil, fmt.Errorf("color %q must be a six character hex code", info.Color)
+	}
+
+	label := &github.Label{
+		Name:  github.Ptr(info.Name),
+		Color: github.Ptr(color),
+	}
+	if info.Description != "" {
+		label.Description = github.Ptr(info.Description)
+	}
+	return label, nil
+}
+
+func desiredRepository(info config.RepositorySettings) (*github.Repository, error) {
+	repository := &github.Repository{
+		AllowAutoMerge:      boolOr(info.AllowAutoMerge, true),
+		AllowMergeCommit:    info.AllowMergeCommit,
+		AllowSquashMerge:    info.AllowSquashMerge,
+		AllowRebaseMerge:    info.AllowRebaseMerge,
+		DeleteBranchOnMerge: boolOr(info.DeleteBranchOnMerge, true),
+		HasWiki:             boolOr(info.HasWiki, false),
+		HasProjects:         boolOr(info.HasProjects, false),
+		IsTemplate:          info.IsTemplate,
+	}
+
+	if info.Description != "" {
+		repository.Description = github.Ptr(info.Description)
+	}
+	if info.Homepage != "" {
+		repository.Homepage = github.Ptr(info.Homepage)
+	}
+	if info.Visibility != "" {
+		if !slices.Contains(visibilities, info.Visibility) {
+			return nil, fmt.Errorf("unsupported visibility %q, must be one of %v", info.Visibility, visibilities)
+		}
+		repository.Visibility = github.Ptr(info.Visibility)
+	}
+	if info.SquashMergeCommitTitle != "" {
+		if !slices.Contains(squashMergeCommitTitles, info.SquashMergeCommitTitle) {
+			return nil, fmt.Errorf("unsupported squash merge commit title %q, must be one of %v", info.SquashMergeCommitTitle, squashMergeCommitTitles)
+		}
+		repository.SquashMergeCommitTitle = github.Ptr(info.SquashMergeCommitTitle)
+	}
+
+	return repository, nil
+}
+
+// PlanSettings compares the desired settings with the current state of the
+// repository and returns the changes needed to reconcile them, in the order
+// they must be applied. Resources that already match are left out.
+func (c *Client) PlanSettings(ctx context.Context, organization, repo string, settings Settings) (Plan, error) {
+	current, _, err := c.Client.Repositories.Get(ctx, organization, repo)
+	if err != nil && !isNotFound(err) {
+		return Plan{}, fmt.Errorf("fetching repository: %w", err)
+	}
+	exists := err == nil
+
+	plan := Plan{}
+	for _, planner := range []func(context.Context, string, string, bool, *github.Repository, Settings) ([]Change, error){
+		c.planRepository,
+		c.planTopics,
+		c.planRulesets,
+		c.planWorkflowPermissions,
+		c.planLabels,
+		c.planSecrets,
+	} {
+		changes, err := planner(ctx, organization, repo, exists, current, settings)
+		if err != nil {
+			return Plan{}, err
+		}
+		plan.Changes = append(plan.Changes, changes...)
+	}
+
+	return plan, nil
+}
+
+// ApplySettings reconciles the repository with the desired settings,
+// returning the plan that was applied.
+func (c *Client) ApplySettings(ctx context.Context, organization, repo string, settings Settings) (Plan, error) {
+	plan, err := c.PlanSettings(ctx, organization, repo, settings)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	return plan, plan.Apply(ctx)
+}
+
+func (c *Client) planRepository(ctx context.Context, organization, repo string, exists bool, current *github.Repository, settings Settings) ([]Change, error) {
+	fields, err := diffFields(current, settings.Repository)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		user, err := c.User(ctx)
+		if err != nil {
+			return nil, err
+		}
+		owner := organization
+		if user == organization {
+			owner = ""
+		}
+
+		desired := *settings.Repository
+		desired.Name = github.Ptr(repo)
+		return []Change{{
+			Action:   ActionCreate,
+			Resource: "repository",
+			Fields:   fields,
+			apply: func(ctx context.Context) error {
+				_, _, err := c.Client.Repositories.Create(ctx, owner, &desired)
+				return err
+			},
+		}}, nil
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return []Change{{
+		Action:   ActionUpdate,
+		Resource: "repository",
+		Fields:   fields,
+		apply: func(ctx context.Context) error {
+			_, _, err := c.Client.Repositories.Edit(ctx, organization, repo, settings.Repository)
+			return err
+		},
+	}}, nil
+}
+
+func (c *Client) planTopics(ctx context.Context, organization, repo string, exists bool, current *github.Repository, settings Settings) ([]Change, error) {
+	if settings.Topics == nil {
+		return nil, nil
+	}
+
+	topics := []string{}
+	if exists {
+		topics = slices.Sorted(slices.Values(current.Topics))
+	}
+	if slices.Equal(topics, settings.Topics) {
+		return nil, nil
+	}
+
+	fields, err := diffFields(map[string][]string{"topics": topics}, map[string][]string{"topics": settings.Topics})
+	if err != nil {
+		return nil, err
+	}
+	return []Change{{
+		Action:   ActionUpdate,
+		Resource: "repository topics",
+		Fields:   fields,
+		apply: func(ctx context.Context) error {
+			_, _, err := c.Client.Repositories.ReplaceAllTopics(ctx, organization, repo, settings.Topics)
+			return err
+		},
+	}}, nil
+}
+
+func (c *Client) planRulesets(ctx context.Context, organization, repo string, exists bool, _ *github.Repository, settings Settings) ([]Change, error) {
+	rulesets := map[string]*github.RepositoryRuleset{}
+	if exists {
+		existing, _, err := c.Client.Repositories.GetAllRulesets(ctx, organization, repo, false)
+		if err != nil {
+			return nil, fmt.Errorf("listing rulesets: %w", err)
+		}
+		for _, ruleset := range existing {
+			rulesets[ruleset.Name] = ruleset
+		}
+	}
+
+	changes := []Change{}
+	for _, ruleset := range settings.Rulesets {
+		resource := fmt.Sprintf("ruleset %q", ruleset.Name)
+
+		summary, ok := rulesets[ruleset.Name]
+		if !ok {
+			fields, err := diffFields(nil, ruleset, rulesetFields...)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, Change{
+				Action:   ActionCreate,
+				Resource: resource,
+				Fields:   fields,
+				apply: func(ctx context.Context) error {
+					_, _, err := c.Client.Repositories.CreateRuleset(ctx, organization, repo, ruleset)
+					return err
+				},
+			})
+			continue
+		}
+
+		id := summary.GetID()
+		existing, _, err := c.Client.Repositories.GetRuleset(ctx, organization, repo, id, false)
+		if err != nil {
+			return nil, fmt.Errorf("fetching %s: %w", resource, err)
+		}
+
+		fields, err := diffFields(normalizeRuleset(*existing), ruleset, rulesetFields...)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) != 0 {
+			changes = append(changes, Change{
+				Action:   ActionUpdate,
+				Resource: resource,
+				Fields:   fields,
+				apply: func(ctx context.Context) error {
+					_, _, err := c.Client.Repositories.UpdateRuleset(ctx, organization, repo, id, ruleset)
+					return err
+				},
+			})
+		}
+	}
+
+	// the status check rulesets are managed by templater, so they're
+	// removed once no checks are required, otherwise they'd keep
+	// requiring checks that never report
+	for _, name := range []string{statusChecksRuleset, backportStatusChecksRuleset} {
+		summary, ok := rulesets[name]
+		if !ok || slices.ContainsFunc(settings.Rulesets, func(ruleset github.RepositoryRuleset) bool {
+			return ruleset.Name == name
+		}) {
+			continue
+		}
+
+		id := summary.GetID()
+		changes = append(changes, Change{
+			Action:   ActionDelete,
+			Resource: fmt.Sprintf("ruleset %q", name),
+			apply: func(ctx context.Context) error {
+				_, err := c.Client.Repositories.DeleteRuleset(ctx, organization, repo, id)
+				return err
+			},
+		})
+	}
+	return changes, nil
+}
+
+func (c *Client) planWorkflowPermissions(ctx context.Context, organization, repo string, exists bool, _ *github.Repository, settings Settings) ([]Change, error) {
+	var permissions *github.DefaultWorkflowPermissionRepository
+	if exists {
+		var err error
+		permissions, _, err = c.Client.Repositories.GetDefaultWorkflowPermissions(ctx, organization, repo)
+		if err != nil {
+			return nil, fmt.Errorf("fetching workflow permissions: %w", err)
+		}
+	}
+
+	fields, err := diffFields(permissions, settings.WorkflowPermissions)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return []Change{{
+		Action:   ActionUpdate,
+		Resource: "workflow permissions",
+		Fields:   fields,
+		apply: func(ctx context.Context) error {
+			_, _, err := c.Client.Repositories.EditDefaultWorkflowPermissions(ctx, organization, repo, settings.WorkflowPermissions)
+			return err
+		},
+	}}, nil
+}
+
+func (c *Client) planLabels(ctx context.Context, organization, repo string, exists bool, _ *github.Repository, settings Settings) ([]Change, error) {
+	labels := map[string]*github.Label{}
+	if exists {
+		options := &github.ListOptions{PerPage: 100}
+		for {
+			page, response, err := c.Client.Issues.ListLabels(ctx, organization, repo, options)
+			if err != nil {
+				return nil, fmt.Errorf("listing labels: %w", err)
+			}
+			for _, label := range page {
+				labels[strings.ToLower(label.GetName())] = label
+			}
+			if response.NextPage == 0 {
+				break
+			}
+			options.Page = response.NextPage
+		}
+	}
+
+	changes := []Change{}
+	desired := map[string]bool{}
+	for _, label := range settings.Labels {
+		resource := fmt.Sprintf("label %q", label.GetName())
+		desired[strings.ToLower(label.GetName())] = true
+
+		existing, ok := labels[strings.ToLower(label.GetName())]
+		if !ok {
+			fields, err := diffFields(nil, label)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, Change{
+				Action:   ActionCreate,
+				Resource: resource,
+				Fields:   fields,
+				apply: func(ctx context.Context) error {
+					_, _, err := c.Client.Issues.CreateLabel(ctx, organization, repo, label)
+					return err
+				},
+			})
+			continue
+		}
+
+		fields, err := diffFields(existing, label)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) != 0 {
+			changes = append(changes, Change{
+				Action:   ActionUpdate,
+				Resource: resource,
+				Fields:   fields,
+				apply: func(ctx context.Context) error {
+					_, _, err := c.Client.Issues.EditLabel(ctx, organization, repo, existing.GetName(), label)
+					return err
+				},
+			})
+		}
+	}
+
+	if !settings.PruneLabels {
+		return changes, nil
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		if desired[key] {
+			continue
+		}
+		name := labels[key].GetName()
+		changes = append(changes, Change{
+			Action:   ActionDelete,
+			Resource: fmt.Sprintf("label %q", name),
+			apply: func(ctx context.Context) error {
+				_, err := c.Client.Issues.DeleteLabel(ctx, organization, repo, name)
+				return err
+			},
+		})
+	}
+	return changes, nil
+}
+
+// PlanLabels compares the desired labels with the labels of an existing
+// repository and returns the changes needed to reconcile them.
+func (c *Client) PlanLabels(ctx context.Context, organization, repo string, settings Settings) (Plan, error) {
+	changes, err := c.planLabels(ctx, organization, repo, true, nil, settings)
+	if err != nil {
+		return Plan{}, err
+	}
+	return Plan{Changes: changes}, nil
+}
+
+func (c *Client) planSecrets(ctx context.Context, organization, repo string, exists bool, _ *github.Repository, settings Settings) ([]Change, error) {
+	existing := map[string]bool{}
+	if exists {
+		secrets, err := listSecrets(ctx, func(options *github.ListOptions) (*github.Secrets, *github.Response, error) {
+			return c.Client.Actions.ListRepoSecrets(ctx, organization, repo, options)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing secrets: %w", err)
+		}
+		for _, secret := range secrets {
+			existing[secret.Name] = true
+		}
+	}
+
+	changes := []Change{}
+	for _, name := range settings.Secrets {
+		if existing[name] {
+			continue
+		}
+		changes = append(changes, Change{
+			Action:   ActionCreate,
+			Resource: fmt.Sprintf("secret %q", name),
+			apply: func(ctx context.Context) error {
+				if settings.SecretValue == nil {
+					return errors.New("no secret value provided")
+				}
+				value, err := settings.SecretValue(name)
+				if err != nil {
+					return err
+				}
+				if c.organization != organization || c.repo != repo || c.keys == nil {
+					if _, err := c.SetRepository(ctx, organization, repo); err != nil {
+						return err
+					}
+				}
+				return c.SetEncryptedSecret(ctx, name, value)
+			},
+		})
+	}
+	return changes, nil
+}
+
+func isNotFound(err error) bool {
+	var response *github.ErrorResponse
+	return errors.As(err, &response) && response.Response != nil && response.Response.StatusCode == http.StatusNotFound
+}
+
+func boolOr(value *bool, fallback bool) *bool {
+	if value == nil {
+		return github.Ptr(fallback)
+	}
+	return value
+}
@@ -0,0 +1,332 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v69/github"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+)
+
+func testSettings(t *testing.T) Settings {
+	t.Helper()
+
+	settings, err := DesiredSettings(config.ConfigFile{
+		GithubInfo: config.GithubInfo{Organization: "org", Repository: "repo"},
+		Backports: config.BackportInfo{
+			Versions: []string{"v1.0.0"},
+			Bot:      config.BotInfo{TokenVariable: "BOT_TOKEN"},
+		},
+	})
+	require.NoError(t, err)
+	settings.SecretValue = func(name string) (string, error) {
+		return "value", nil
+	}
+	return settings
+}
+
+func resources(plan Plan) []string {
+	resources := []string{}
+	for _, change := range plan.Changes {
+		resources = append(resources, string(change.Action)+" "+change.Resource)
+	}
+	return resources
+}
+
+func TestDesiredSettings(t *testing.T) {
+	disabled, enabled := false, true
+
+	for name, tt := range map[string]struct {
+		info        config.GithubInfo
+		repository  string
+		permissions string
+		rulesets    []string
+		err         string
+	}{
+		"defaults": {
+			repository:  `{"allow_auto_merge":true,"delete_branch_on_merge":true,"has_wiki":false,"has_projects":false}`,
+			permissions: `{"default_workflow_permissions":"write","can_approve_pull_request_reviews":true}`,
+			rulesets:    []string{"Require PR"},
+		},
+		"overrides": {
+			info: config.GithubInfo{
+				Settings: config.RepositorySettings{
+					AllowAutoMerge:      &disabled,
+					DeleteBranchOnMerge: &disabled,
+					HasWiki:             &enabled,
+					HasProjects:         &enabled,
+				},
+				WorkflowPermissions: config.WorkflowPermissions{
+					Default:                      "read",
+					CanApprovePullRequestReviews: &disabled,
+				},
+				Rulesets: []config.RulesetInfo{{Name: "Main"}, {Name: "Backports"}},
+			},
+			repository:  `{"allow_auto_merge":false,"delete_branch_on_merge":false,"has_wiki":true,"has_projects":true}`,
+			permissions: `{"default_workflow_permissions":"read","can_approve_pull_request_reviews":false}`,
+			rulesets:    []string{"Main", "Backports"},
+		},
+		"invalid workflow permissions": {
+			info: config.GithubInfo{WorkflowPermissions: config.WorkflowPermissions{Default: "writes"}},
+			err:  `unsupported default workflow permissions "writes", must be one of [read write]`,
+		},
+		"invalid ruleset": {
+			info: config.GithubInfo{Rulesets: []config.RulesetInfo{{Name: "Main", Target: "push"}}},
+			err:  `ruleset "Main": unsupported target "push", must be one of [branch tag]`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			settings, err := DesiredSettings(config.ConfigFile{GithubInfo: tt.info})
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+
+			repository, err := json.Marshal(settings.Repository)
+			require.NoError(t, err)
+			require.JSONEq(t, tt.repository, string(repository))
+
+			permissions, err := json.Marshal(settings.WorkflowPermissions)
+			require.NoError(t, err)
+			require.JSONEq(t, tt.permissions, string(permissions))
+
+			rulesets := []string{}
+			for _, ruleset := range settings.Rulesets {
+				rulesets = append(rulesets, ruleset.Name)
+			}
+			require.Equal(t, tt.rulesets, rulesets)
+		})
+	}
+}
+
+func TestPlanSettingsNewRepository(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGithub(t)
+	client := fake.client(t)
+
+	plan, err := client.PlanSettings(ctx, "org", "repo", testSettings(t))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`create repository`,
+		`create ruleset "Require PR"`,
+		`update workflow permissions`,
+		`create label "v1.0.0"`,
+		`create label "no-changelog"`,
+		`create label "stale"`,
+		`create secret "BOT_TOKEN"`,
+		`create secret "SLACK_WEBHOOK_URL"`,
+	}, resources(plan))
+	require.Empty(t, fake.writes())
+
+	require.NoError(t, plan.Apply(ctx))
+	require.Equal(t, "repo", fake.repository.GetName())
+	require.Len(t, fake.rulesets, 1)
+	require.Equal(t, "write", fake.permissions.GetDefaultWorkflowPermissions())
+	require.Len(t, fake.labels, 3)
+	require.Contains(t, fake.secrets, "BOT_TOKEN")
+	require.Contains(t, fake.secrets, "SLACK_WEBHOOK_URL")
+	require.Contains(t, fake.writes(), "POST /orgs/org/repos")
+
+	plan, err = client.PlanSettings(ctx, "org", "repo", testSettings(t))
+	require.NoError(t, err)
+	require.True(t, plan.Empty())
+}
+
+func TestPlanSettingsDrift(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGithub(t)
+	client := fake.client(t)
+
+	_, err := client.ApplySettings(ctx, "org", "repo", testSettings(t))
+	require.NoError(t, err)
+
+	fake.repository.AllowAutoMerge = github.Ptr(false)
+	fake.labels["stale"].Color = github.Ptr("ffffff")
+	fake.rulesets[1].Rules.PullRequest.RequiredApprovingReviewCount = 2
+	delete(fake.secrets, "SLACK_WEBHOOK_URL")
+	fake.requests = nil
+
+	plan, err := client.PlanSettings(ctx, "org", "repo", testSettings(t))
+	require.NoError(t, err)
+
+	var output bytes.Buffer
+	require.NoError(t, plan.Write(&output))
+	require.Equal(t, `  ~ update repository
+      ~ allow_auto_merge = false -> true
+  ~ update ruleset "Require PR"
+      ~ rules = [{"type":"deletion"},{"type":"pull_request","parameters":{"allowed_merge_methods":["merge","rebase","squash"],"dismiss_stale_reviews_on_push":false,"require_code_owner_review":false,"require_last_push_approval":false,"required_approving_review_count":2,"required_review_thread_resolution":false}},{"type":"non_fast_forward"}] -> [{"type":"deletion"},{"type":"pull_request","parameters":{"allowed_merge_methods":["merge","rebase","squash"],"dismiss_stale_reviews_on_push":false,"require_code_owner_review":false,"require_last_push_approval":false,"required_approving_review_count":1,"required_review_thread_resolution":false}},{"type":"non_fast_forward"}]
+  ~ update label "stale"
+      ~ color = "ffffff" -> "8f1402"
+  + create secret "SLACK_WEBHOOK_URL"
+
+Plan: 1 to create, 3 to update, 0 to delete.
+`, output.String())
+
+	require.NoError(t, plan.Apply(ctx))
+	require.Equal(t, []string{
+		"PATCH /repos/org/repo",
+		"PUT /repos/org/repo/rulesets/1",
+		"PATCH /repos/org/repo/labels/stale",
+		"PUT /repos/org/repo/actions/secrets/SLACK_WEBHOOK_URL",
+	}, fake.writes())
+
+	plan, err = client.PlanSettings(ctx, "org", "repo", testSettings(t))
+	require.NoError(t, err)
+	require.True(t, plan.Empty())
+}
+
+func TestPlanSettingsMetadata(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGithub(t)
+	client := fake.client(t)
+
+	cfg := config.ConfigFile{
+		GithubInfo: config.GithubInfo{
+			Organization: "org",
+			Repository:   "repo",
+			Settings: config.RepositorySettings{
+				Description:            "A templated repository",
+				Homepage:               "https://example.com",
+				Topics:                 []string{"Templates", "go"},
+				Visibility:             "private",
+				AllowMergeCommit:       github.Ptr(false),
+				SquashMergeCommitTitle: "PR_TITLE",
+				IsTemplate:             github.Ptr(true),
+			},
+		},
+	}
+	settings, err := DesiredSettings(cfg)
+	require.NoError(t, err)
+	settings.Secrets = nil
+
+	plan, err := client.PlanSettings(ctx, "org", "repo", settings)
+	require.NoError(t, err)
+	require.Equal(t, []string{"create repository", "update repository topics"}, resources(plan)[:2])
+	require.NoError(t, plan.Apply(ctx))
+
+	require.Equal(t, "A templated repository", fake.repository.GetDescription())
+	require.Equal(t, "private", fake.repository.GetVisibility())
+	require.Equal(t, "PR_TITLE", fake.repository.GetSquashMergeCommitTitle())
+	require.True(t, fake.repository.GetIsTemplate())
+	require.Equal(t, []string{"go", "templates"}, fake.repository.Topics)
+
+	plan, err = client.PlanSettings(ctx, "org", "repo", settings)
+	require.NoError(t, err)
+	require.True(t, plan.Empty())
+
+	fake.repository.Topics = []string{"go"}
+	fake.repository.Homepage = nil
+	plan, err = client.PlanSettings(ctx, "org", "repo", settings)
+	require.NoError(t, err)
+
+	var output bytes.Buffer
+	require.NoError(t, plan.Write(&output))
+	require.Equal(t, `  ~ update repository
+      ~ homepage = null -> "https://example.com"
+  ~ update repository topics
+      ~ topics = ["go"] -> ["go","templates"]
+
+Plan: 0 to create, 2 to update, 0 to delete.
+`, output.String())
+
+	cfg.GithubInfo.Settings.Visibility = "hidden"
+	_, err = DesiredSettings(cfg)
+	require.EqualError(t, err, `unsupported visibility "hidden", must be one of [public private internal]`)
+}
+
+func TestApplySettingsPartialFailure(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGithub(t)
+	client := fake.client(t)
+
+	settings := testSettings(t)
+	settings.Secrets = nil
+
+	fake.failures["POST /repos/org/repo/rulesets"] = http.StatusUnprocessableEntity
+	_, err := client.InitializeRepository(ctx, "org", "repo", settings)
+
+	var applyErr *ApplyError
+	require.ErrorAs(t, err, &applyErr)
+	require.True(t, applyErr.CreatedRepository())
+	require.Equal(t, []string{"create repository"}, resources(Plan{Changes: applyErr.Applied}))
+	require.Equal(t, `create ruleset "Require PR"`, resources(Plan{Changes: applyErr.Remaining})[0])
+
+	t.Run("resume", func(t *testing.T) {
+		delete(fake.failures, "POST /repos/org/repo/rulesets")
+		fake.requests = nil
+
+		url, err := client.InitializeRepository(ctx, "org", "repo", settings)
+		require.NoError(t, err)
+		require.Equal(t, "git@github.com:org/repo.git", url)
+		require.NotContains(t, fake.writes(), "POST /orgs/org/repos")
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		fake.failures["PUT /repos/org/repo/actions/permissions/workflow"] = http.StatusInternalServerError
+		fake.permissions.DefaultWorkflowPermissions = github.Ptr("read")
+
+		_, err := client.InitializeRepository(ctx, "org", "repo", settings)
+		require.ErrorAs(t, err, &applyErr)
+		require.False(t, applyErr.CreatedRepository())
+
+		require.NoError(t, client.DeleteRepository(ctx, "org", "repo"))
+		require.Nil(t, fake.repository)
+	})
+}
+
+func TestPlanLabels(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGithub(t)
+	client := fake.client(t)
+
+	fake.repository = &github.Repository{Name: github.Ptr("repo")}
+	fake.labels["bug"] = &github.Label{Name: github.Ptr("bug"), Color: github.Ptr("d73a4a")}
+	fake.labels["stale"] = &github.Label{Name: github.Ptr("stale"), Color: github.Ptr("ffffff")}
+	fake.labels["v1.0.0"] = &github.Label{Name: github.Ptr("v1.0.0"), Color: github.Ptr("ededed")}
+
+	cfg := config.ConfigFile{
+		Backports: config.BackportInfo{Versions: []string{"v1.0.0", "v1.1.0"}},
+		Labels: []config.LabelInfo{
+			{Name: "stale", Color: "#8F1402", Description: "No activity"},
+			{Name: "kind/bug", Color: "d73a4a"},
+		},
+	}
+	settings, err := DesiredSettings(cfg)
+	require.NoError(t, err)
+
+	plan, err := client.PlanLabels(ctx, "org", "repo", settings)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`create label "v1.1.0"`,
+		`create label "no-changelog"`,
+		`update label "stale"`,
+		`create label "kind/bug"`,
+	}, resources(plan))
+
+	settings.PruneLabels = true
+	plan, err = client.PlanLabels(ctx, "org", "repo", settings)
+	require.NoError(t, err)
+	require.Equal(t, `delete label "bug"`, resources(plan)[len(plan.Changes)-1])
+
+	require.NoError(t, plan.Apply(ctx))
+	require.NotContains(t, fake.labels, "bug")
+	require.Equal(t, "No activity", fake.labels["stale"].GetDescription())
+	require.Equal(t, "8f1402", fake.labels["stale"].GetColor())
+
+	plan, err = client.PlanLabels(ctx, "org", "repo", settings)
+	require.NoError(t, err)
+	require.True(t, plan.Empty())
+
+	cfg.Labels = []config.LabelInfo{{Name: "invalid", Color: "red"}}
+	_, err = DesiredSettings(cfg)
+	require.EqualError(t, err, `label "invalid": color "red" must be a six character hex code`)
+}
@@ -0,0 +1,222 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v69/github"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+	"github.com/andrewstucki/actions-testing/templater/secrets"
+)
+
+var (
+	variableScopes       = []string{secrets.ScopeRepository, secrets.ScopeEnvironment, secrets.ScopeOrganization}
+	variableVisibilities = []string{secrets.VisibilityAll, secrets.VisibilityPrivate, secrets.VisibilitySelected}
+)
+
+// DesiredVariables validates the configured Actions variables, defaulting
+// them to the repository scope and organization variables to being visible
+// to selected repositories.
+func DesiredVariables(cfg config.ConfigFile) ([]config.VariableInfo, error) {
+	variables := []config.VariableInfo{}
+	seen := map[string]struct{}{}
+	for _, variable := range cfg.Variables {
+		if variable.Scope == "" {
+			variable.Scope = secrets.ScopeRepository
+		}
+		if variable.Scope == secrets.ScopeOrganization && variable.Visibility == "" {
+			variable.Visibility = secrets.VisibilitySelected
+		}
+		if err := validateVariable(variable); err != nil {
+			return nil, fmt.Errorf("invalid variable %q: %w", variable.Name, err)
+		}
+
+		key := strings.ToUpper(variable.Scope + "/" + variable.Environment + "/" + variable.Name)
+		if _, ok := seen[key]; ok {
+			return nil, fmt.Errorf("variable %q is declared more than once", variable.Name)
+		}
+		seen[key] = struct{}{}
+
+		variables = append(variables, variable)
+	}
+	return variables, nil
+}
+
+func validateVariable(variable config.VariableInfo) error {
+	if err := secrets.ValidateName(variable.Name); err != nil {
+		return err
+	}
+
+	switch variable.Scope {
+	case secrets.ScopeEnvironment:
+		if variable.Environment == "" {
+			return errors.New("environment is required for environment variables")
+		}
+	case secrets.ScopeRepository, secrets.ScopeOrganization:
+		if variable.Environment != "" {
+			return fmt.Errorf("environment is only supported for %s variables", secrets.ScopeEnvironment)
+		}
+	default:
+		return fmt.Errorf("unsupported scope %q, must be one of %v", variable.Scope, variableScopes)
+	}
+
+	if variable.Scope != secrets.ScopeOrganization {
+		if variable.Visibility != "" || len(variable.Repositories) != 0 {
+			return fmt.Errorf("visibility and repositories are only supported for %s variables", secrets.ScopeOrganization)
+		}
+		return nil
+	}
+	if !slices.Contains(variableVisibilities, variable.Visibility) {
+		return fmt.Errorf("unsupported visibility %q, must be one of %v", variable.Visibility, variableVisibilities)
+	}
+	if variable.Visibility != secrets.VisibilitySelected && len(variable.Repositories) != 0 {
+		return fmt.Errorf("repositories are only supported with %s visibility", secrets.VisibilitySelected)
+	}
+	return nil
+}
+
+// PlanVariables compares the given Actions variables with the ones set on
+// GitHub, returning the changes needed to create or update them. Only the
+// value and visibility are compared, the repositories an organization
+// variable is shared with are set whenever it's created or updated.
+func (c *Client) PlanVariables(ctx context.Context, organization, repo string, variables []config.VariableInfo) (Plan, error) {
+	c.useRepository(organization, repo)
+
+	repository, err := listVariables(ctx, func(options *github.ListOptions) (*github.ActionsVariables, *github.Response, error) {
+		return c.Client.Actions.ListRepoVariables(ctx, organization, repo, options)
+	})
+	if err != nil {
+		return Plan{}, fmt.Errorf("listing variables: %w", err)
+	}
+
+	environments := map[string]map[string]*github.ActionsVariable{}
+	changes := []Change{}
+	for _, variable := range variables {
+		desired := &github.ActionsVariable{Name: variable.Name, Value: variable.Value}
+
+		var existing *github.ActionsVariable
+		var resource string
+		var create, update func(ctx context.Context) error
+
+		switch variable.Scope {
+		case secrets.ScopeEnvironment:
+			current, ok := environments[variable.Environment]
+			if !ok {
+				current, err = listVariables(ctx, func(options *github.ListOptions) (*github.ActionsVariables, *github.Response, error) {
+					return c.Client.Actions.ListEnvVariables(ctx, organization, repo, variable.Environment, options)
+				})
+				if err != nil && !isNotFound(err) {
+					return Plan{}, fmt.Errorf("listing environment %q variables: %w", variable.Environment, err)
+				}
+				environments[variable.Environment] = current
+			}
+
+			existing = current[variable.Name]
+			resource = fmt.Sprintf("environment %q variable %q", variable.Environment, variable.Name)
+			create = func(ctx context.Context) error {
+				if err := c.ensureEnvironment(ctx, variable.Environment); err != nil {
+					return err
+				}
+				_, err := c.Client.Actions.CreateEnvVariable(ctx, organization, repo, variable.Environment, desired)
+				return err
+			}
+			update = func(ctx context.Context) error {
+				_, err := c.Client.Actions.UpdateEnvVariable(ctx, organization, repo, variable.Environment, desired)
+				return err
+			}
+		case secrets.ScopeOrganization:
+			desired.Visibility = github.Ptr(variable.Visibility)
+
+			existing, _, err = c.Client.Actions.GetOrgVariable(ctx, organization, variable.Name)
+			if err != nil && !isNotFound(err) {
+				return Plan{}, fmt.Errorf("getting organization variable %q: %w", variable.Name, err)
+			}
+
+			resource = fmt.Sprintf("organization variable %q", variable.Name)
+			withRepositories := func(ctx context.Context) (*github.ActionsVariable, error) {
+				if variable.Visibility != secrets.VisibilitySelected {
+					return desired, nil
+				}
+				ids, err := c.selectedRepositoryIDs(ctx, variable.Repositories)
+				if err != nil {
+					return nil, err
+				}
+				selected := *desired
+				selected.SelectedRepositoryIDs = github.Ptr(github.SelectedRepoIDs(ids))
+				return &selected, nil
+			}
+			create = func(ctx context.Context) error {
+				desired, err := withRepositories(ctx)
+				if err != nil {
+					return err
+				}
+				_, err = c.Client.Actions.CreateOrgVariable(ctx, organization, desired)
+				return err
+			}
+			update = func(ctx context.Context) error {
+				desired, err := withRepositories(ctx)
+				if err != nil {
+					return err
+				}
+				_, err = c.Client.Actions.UpdateOrgVariable(ctx, organization, desired)
+				return err
+			}
+		default:
+			existing = repository[variable.Name]
+			resource = fmt.Sprintf("variable %q", variable.Name)
+			create = func(ctx context.Context) error {
+				_, err := c.Client.Actions.CreateRepoVariable(ctx, organization, repo, desired)
+				return err
+			}
+			update = func(ctx context.Context) error {
+				_, err := c.Client.Actions.UpdateRepoVariable(ctx, organization, repo, desired)
+				return err
+			}
+		}
+
+		if existing == nil {
+			fields, err := diffFields(nil, desired, "value", "visibility")
+			if err != nil {
+				return Plan{}, err
+			}
+			changes = append(changes, Change{Action: ActionCreate, Resource: resource, Fields: fields, apply: create})
+			continue
+		}
+
+		fields, err := diffFields(existing, desired, "value", "visibility")
+		if err != nil {
+			return Plan{}, err
+		}
+		if len(fields) != 0 {
+			changes = append(changes, Change{Action: ActionUpdate, Resource: resource, Fields: fields, apply: update})
+		}
+	}
+	return Plan{Changes: changes}, nil
+}
+
+// listVariables pages through one of the variable listing endpoints,
+// returning the variables by name.
+func listVariables(ctx context.Context, list func(options *github.ListOptions) (*github.ActionsVariables, *github.Response, error)) (map[string]*github.ActionsVariable, error) {
+	variables := map[string]*github.ActionsVariable{}
+	options := &github.ListOptions{PerPage: 100}
+	for {
+		page, response, err := list(options)
+		if err != nil {
+			return variables, err
+		}
+		for _, variable := range page.Variables {
+			variables[variable.Name] = variable
+		}
+		if response.NextPage == 0 {
+			return variables, nil
+		}
+		options.Page = response.NextPage
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package github
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/go-github/v69/github"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+)
+
+func TestDesiredVariables(t *testing.T) {
+	variables, err := DesiredVariables(config.ConfigFile{Variables: []config.VariableInfo{
+		{Name: "SLACK_CHANNEL", Value: "#releases"},
+		{Name: "REGION", Value: "us-east-1", Scope: "environment", Environment: "production"},
+		{Name: "BOT_NAME", Value: "bot", Scope: "org"},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, []config.VariableInfo{
+		{Name: "SLACK_CHANNEL", Value: "#releases", Scope: "repo"},
+		{Name: "REGION", Value: "us-east-1", Scope: "environment", Environment: "production"},
+		{Name: "BOT_NAME", Value: "bot", Scope: "org", Visibility: "selected"},
+	}, variables)
+
+	for name, tt := range map[string]struct {
+		variable config.VariableInfo
+		err      string
+	}{
+		"reserved prefix": {
+			variable: config.VariableInfo{Name: "GITHUB_CHANNEL"},
+			err:      `invalid variable "GITHUB_CHANNEL": name must not start with GITHUB_`,
+		},
+		"missing environment": {
+			variable: config.VariableInfo{Name: "REGION", Scope: "environment"},
+			err:      `invalid variable "REGION": environment is required for environment variables`,
+		},
+		"unsupported visibility": {
+			variable: config.VariableInfo{Name: "BOT_NAME", Scope: "org", Visibility: "public"},
+			err:      `invalid variable "BOT_NAME": unsupported visibility "public", must be one of [all private selected]`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := DesiredVariables(config.ConfigFile{Variables: []config.VariableInfo{tt.variable}})
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestPlanVariables(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeGithub(t)
+	fake.repository = &github.Repository{ID: github.Ptr(int64(7)), Name: github.Ptr("repo")}
+	fake.variables["repo/SLACK_CHANNEL"] = &github.ActionsVariable{Name: "SLACK_CHANNEL", Value: "#general"}
+	client := fake.client(t)
+
+	variables, err := DesiredVariables(config.ConfigFile{Variables: []config.VariableInfo{
+		{Name: "SLACK_CHANNEL", Value: "#releases"},
+		{Name: "REGION", Value: "us-east-1", Scope: "environment", Environment: "production"},
+		{Name: "BOT_NAME", Value: "bot", Scope: "org"},
+	}})
+	require.NoError(t, err)
+
+	plan, err := client.PlanVariables(ctx, "org", "repo", variables)
+	require.NoError(t, err)
+
+	var buffer bytes.Buffer
+	require.NoError(t, plan.Write(&buffer))
+	require.Equal(t, `  ~ update variable "SLACK_CHANNEL"
+      ~ value = "#general" -> "#releases"
+  + create environment "production" variable "REGION"
+      + value = "us-east-1"
+  + create organization variable "BOT_NAME"
+      + value = "bot"
+      + visibility = "selected"
+
+Plan: 2 to create, 1 to update, 0 to delete.
+`, buffer.String())
+
+	require.NoError(t, plan.Apply(ctx))
+	require.True(t, fake.environments["production"])
+	require.Equal(t, "#releases", fake.variables["repo/SLACK_CHANNEL"].Value)
+	require.Equal(t, "us-east-1", fake.variables["environment/production/REGION"].Value)
+	require.Equal(t, github.Ptr(github.SelectedRepoIDs{7}), fake.variables["org/BOT_NAME"].SelectedRepositoryIDs)
+
+	plan, err = client.PlanVariables(ctx, "org", "repo", variables)
+	require.NoError(t, err)
+	require.True(t, plan.Empty())
+}
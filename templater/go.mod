@@ -3,6 +3,7 @@ module github.com/andrewstucki/actions-testing/templater
 go 1.23.4
 
 require (
+	filippo.io/age v1.2.1
 	github.com/cqroot/prompt v0.9.4
 	github.com/google/go-github/v69 v69.2.0
 	github.com/spf13/cobra v1.9.1
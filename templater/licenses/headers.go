@@ -0,0 +1,203 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package licenses
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+)
+
+var (
+	// commentPrefixes maps each supported match type to the line
+	// comment prefix used when writing its headers.
+	commentPrefixes = map[string]string{
+		"go":         "//",
+		"typescript": "//",
+		"rust":       "//",
+		"python":     "#",
+	}
+
+	// skippedDirectories are never walked when looking for files
+	// that need license headers.
+	skippedDirectories = []string{"node_modules", "target", "testdata", "vendor"}
+
+	generatedPattern = regexp.MustCompile(`(?m)^(//|#) Code generated .* DO NOT EDIT\.$`)
+)
+
+// Header returns the license header lines for files matched by the given
+// match, without trailing newlines.
+func Header(organization, topLevelLicense string, match config.LicenseHeaderMatch) ([]string, error) {
+	prefix, ok := commentPrefixes[match.Type]
+	if !ok {
+		return nil, fmt.Errorf("unsupported license header type %q", match.Type)
+	}
+
+	license := match.License
+	if license == "" {
+		license = topLevelLicense
+	}
+	if license == "" {
+		return nil, fmt.Errorf("no license specified for %q files", match.Type)
+	}
+
+	if match.Short {
+		return []string{
+			prefix + " Copyright (c) " + organization,
+			prefix + " SPDX-License-Identifier: " + license,
+		}, nil
+	}
+
+	return []string{
+		prefix + " Copyright (c) " + organization,
+		prefix,
+		prefix + " Use of this software is governed by the " + license + " license",
+		prefix + " included in the LICENSE file at the root of this repository.",
+		prefix,
+		prefix + " SPDX-License-Identifier: " + license,
+	}, nil
+}
+
+// ApplyHeader returns the content with the given header inserted at the
+// top of the file, replacing any existing license header. It returns
+// whether the content was changed. Generated files are left untouched.
+func ApplyHeader(content []byte, header []string, prefix string) ([]byte, bool) {
+	if generatedPattern.Match(content) {
+		return content, false
+	}
+
+	lines := strings.Split(string(content), "\n")
+
+	start := 0
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "#!") {
+		start = 1
+	}
+
+	end := start
+	for end < len(lines) && strings.HasPrefix(lines[end], prefix) && !isDirective(lines[end]) {
+		end++
+		// anything following the SPDX identifier, like a package
+		// comment, isn't part of the license header
+		if strings.Contains(lines[end-1], "SPDX-License-Identifier") {
+			break
+		}
+	}
+
+	rest := lines[start:]
+	if existing := strings.Join(lines[start:end], "\n"); strings.Contains(existing, "Copyright") || strings.Contains(existing, "SPDX-License-Identifier") {
+		rest = lines[end:]
+	}
+	for len(rest) > 0 && strings.TrimSpace(rest[0]) == "" {
+		rest = rest[1:]
+	}
+
+	updated := slices.Clone(lines[:start])
+	updated = append(updated, header...)
+	if len(rest) > 0 {
+		updated = append(updated, "")
+		updated = append(updated, rest...)
+	} else {
+		updated = append(updated, "")
+	}
+
+	data := []byte(strings.Join(updated, "\n"))
+	return data, !bytes.Equal(data, content)
+}
+
+// UpdateHeaders walks the given root directory and writes license headers
+// into every file selected by the configuration's matches. When check is
+// set no files are written. It returns the paths of all files that were,
+// or in check mode would have been, changed.
+func UpdateHeaders(root string, cfg config.LicenseHeaderConfig, check bool) ([]string, error) {
+	headers := make([][]string, len(cfg.Matches))
+	for i, match := range cfg.Matches {
+		header, err := Header(cfg.Organization, cfg.TopLevelLicense, match)
+		if err != nil {
+			return nil, err
+		}
+		headers[i] = header
+	}
+
+	changed := []string{}
+	err := filepath.WalkDir(root, func(fullPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(root, fullPath)
+		if err != nil {
+			return err
+		}
+		relative = filepath.ToSlash(relative)
+
+		if d.IsDir() {
+			if relative != "." && (strings.HasPrefix(d.Name(), ".") || slices.Contains(skippedDirectories, d.Name())) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		index := matchFor(cfg.Matches, relative)
+		if index < 0 {
+			return nil
+		}
+
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			return err
+		}
+
+		updated, ok := ApplyHeader(content, headers[index], commentPrefixes[cfg.Matches[index].Type])
+		if !ok {
+			return nil
+		}
+
+		changed = append(changed, relative)
+		if check {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(fullPath, updated, info.Mode().Perm())
+	})
+
+	return changed, err
+}
+
+// matchFor returns the index of the most specific match for the file
+// at the given path, or -1 if no match applies.
+func matchFor(matches []config.LicenseHeaderMatch, file string) int {
+	index, length := -1, -1
+	for i, match := range matches {
+		if !strings.HasSuffix(file, match.Extension) {
+			continue
+		}
+
+		directory := strings.Trim(filepath.ToSlash(match.Directory), "/")
+		if directory != "" && directory != "." && !strings.HasPrefix(file, directory+"/") {
+			continue
+		}
+
+		if len(directory) > length {
+			index, length = i, len(directory)
+		}
+	}
+	return index
+}
+
+// isDirective returns whether the line is a Go compiler directive
+// that must not be treated as part of a license header.
+func isDirective(line string) bool {
+	return strings.HasPrefix(line, "//go:") || strings.HasPrefix(line, "// +build")
+}
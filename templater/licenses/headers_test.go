@@ -0,0 +1,115 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package licenses
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+)
+
+func TestApplyHeader(t *testing.T) {
+	header := []string{
+		"// Copyright (c) Org",
+		"// SPDX-License-Identifier: MIT",
+	}
+
+	for name, tt := range map[string]struct {
+		content  string
+		expected string
+		changed  bool
+	}{
+		"missing": {
+			content:  "package main\n",
+			expected: "// Copyright (c) Org\n// SPDX-License-Identifier: MIT\n\npackage main\n",
+			changed:  true,
+		},
+		"present": {
+			content:  "// Copyright (c) Org\n// SPDX-License-Identifier: MIT\n\npackage main\n",
+			expected: "// Copyright (c) Org\n// SPDX-License-Identifier: MIT\n\npackage main\n",
+		},
+		"outdated": {
+			content:  "// Copyright (c) Old Org\n// SPDX-License-Identifier: Apache-2.0\n\npackage main\n",
+			expected: "// Copyright (c) Org\n// SPDX-License-Identifier: MIT\n\npackage main\n",
+			changed:  true,
+		},
+		"package comment": {
+			content:  "// Package main does things.\npackage main\n",
+			expected: "// Copyright (c) Org\n// SPDX-License-Identifier: MIT\n\n// Package main does things.\npackage main\n",
+			changed:  true,
+		},
+		"package comment after header": {
+			content:  "// Copyright (c) Old Org\n// SPDX-License-Identifier: MIT\n// Package main does things.\npackage main\n",
+			expected: "// Copyright (c) Org\n// SPDX-License-Identifier: MIT\n\n// Package main does things.\npackage main\n",
+			changed:  true,
+		},
+		"build constraint": {
+			content:  "//go:build linux\n\npackage main\n",
+			expected: "// Copyright (c) Org\n// SPDX-License-Identifier: MIT\n\n//go:build linux\n\npackage main\n",
+			changed:  true,
+		},
+		"generated": {
+			content:  "// Code generated by stringer. DO NOT EDIT.\n\npackage main\n",
+			expected: "// Code generated by stringer. DO NOT EDIT.\n\npackage main\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			actual, changed := ApplyHeader([]byte(tt.content), header, "//")
+			require.Equal(t, tt.expected, string(actual))
+			require.Equal(t, tt.changed, changed)
+		})
+	}
+}
+
+func TestUpdateHeaders(t *testing.T) {
+	root := t.TempDir()
+
+	files := map[string]string{
+		"main.go":             "package main\n",
+		"module/module.go":    "package module\n",
+		"module/script.py":    "#!/usr/bin/env python3\nprint()\n",
+		"vendor/dep/dep.go":   "package dep\n",
+		".hidden/hidden.go":   "package hidden\n",
+		"module/data/data.go": "// Copyright (c) Org\n// SPDX-License-Identifier: Apache-2.0\n\npackage data\n",
+	}
+	for name, content := range files {
+		fullPath := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+		require.NoError(t, os.WriteFile(fullPath, []byte(content), 0644))
+	}
+
+	cfg := config.LicenseHeaderConfig{
+		Organization:    "Org",
+		TopLevelLicense: "MIT",
+		Matches: []config.LicenseHeaderMatch{
+			{Type: "go", Extension: ".go", Short: true},
+			{Type: "go", Extension: ".go", Directory: "module/data", Short: true, License: "Apache-2.0"},
+			{Type: "python", Extension: ".py", Directory: "module", Short: true},
+		},
+	}
+
+	changed, err := UpdateHeaders(root, cfg, true)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"main.go", "module/module.go", "module/script.py"}, changed)
+
+	data, err := os.ReadFile(filepath.Join(root, "main.go"))
+	require.NoError(t, err)
+	require.Equal(t, "package main\n", string(data))
+
+	changed, err = UpdateHeaders(root, cfg, false)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"main.go", "module/module.go", "module/script.py"}, changed)
+
+	data, err = os.ReadFile(filepath.Join(root, "module", "script.py"))
+	require.NoError(t, err)
+	require.Equal(t, "#!/usr/bin/env python3\n# Copyright (c) Org\n# SPDX-License-Identifier: MIT\n\nprint()\n", string(data))
+
+	changed, err = UpdateHeaders(root, cfg, true)
+	require.NoError(t, err)
+	require.Empty(t, changed)
+}
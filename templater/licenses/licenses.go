@@ -0,0 +1,83 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package licenses
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+var (
+	//go:embed texts
+	licenseTexts embed.FS
+
+	// ErrUnsupported is returned when a license has no embedded text.
+	ErrUnsupported = errors.New("unsupported license")
+
+	// identifiers maps each supported SPDX license identifier to
+	// the embedded text used to render it.
+	identifiers = map[string]string{
+		"Apache-2.0":       "Apache-2.0.txt",
+		"BSD-2-Clause":     "BSD-2-Clause.txt",
+		"BSD-3-Clause":     "BSD-3-Clause.txt",
+		"GPL-3.0-only":     "GPL-3.0.txt",
+		"GPL-3.0-or-later": "GPL-3.0.txt",
+		"ISC":              "ISC.txt",
+		"MIT":              "MIT.txt",
+		"MPL-2.0":          "MPL-2.0.txt",
+		"Unlicense":        "Unlicense.txt",
+	}
+)
+
+// Supported returns the sorted list of supported SPDX license identifiers.
+func Supported() []string {
+	supported := []string{}
+	for identifier := range identifiers {
+		supported = append(supported, identifier)
+	}
+	slices.Sort(supported)
+	return supported
+}
+
+// Validate returns an error if the given SPDX license identifier
+// isn't supported.
+func Validate(identifier string) error {
+	if _, ok := identifiers[identifier]; !ok {
+		return fmt.Errorf("%w %q, must be one of %s", ErrUnsupported, identifier, strings.Join(Supported(), ", "))
+	}
+	return nil
+}
+
+// Render renders the full text of the license with the given SPDX
+// identifier, filling in the copyright holder and year.
+func Render(identifier, holder string, year int) (string, error) {
+	if err := Validate(identifier); err != nil {
+		return "", err
+	}
+
+	data, err := licenseTexts.ReadFile("texts/" + identifiers[identifier])
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := template.New(identifier).Parse(string(data))
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, struct {
+		Holder string
+		Year   int
+	}{holder, year}); err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
@@ -0,0 +1,89 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package licenses
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+)
+
+// deprecatedLicenses maps the deprecated SPDX identifiers the classifier
+// reports, since a license text doesn't say which variant applies, to the
+// current identifiers they stand for.
+var deprecatedLicenses = map[string][]string{
+	"GPL-2.0":  {"GPL-2.0-only", "GPL-2.0-or-later"},
+	"GPL-3.0":  {"GPL-3.0-only", "GPL-3.0-or-later"},
+	"LGPL-2.1": {"LGPL-2.1-only", "LGPL-2.1-or-later"},
+	"LGPL-3.0": {"LGPL-3.0-only", "LGPL-3.0-or-later"},
+}
+
+// Violation is a dependency whose license isn't permitted by a policy.
+type Violation struct {
+	Dependency Dependency
+	Reason     string
+}
+
+func (v Violation) Error() string {
+	return fmt.Sprintf("%s: %s", v.Dependency.Name, v.Reason)
+}
+
+// CheckPolicy returns a violation for every dependency whose license is
+// denied, or not allowed, by the given policy. Deprecated SPDX identifiers
+// match their current variants. When licenses are allowlisted, ones that
+// couldn't be detected always fail. Dependencies covered by a policy
+// exception are skipped as long as the exception's license, if specified,
+// matches the detected one.
+func CheckPolicy(policy config.LicensePolicy, dependencies []Dependency) []Violation {
+	violations := []Violation{}
+	for _, dependency := range dependencies {
+		if isExcepted(policy.Exceptions, dependency) {
+			continue
+		}
+
+		switch {
+		case containsLicense(policy.Denied, dependency.LicenseName):
+			violations = append(violations, Violation{
+				Dependency: dependency,
+				Reason:     fmt.Sprintf("license %s is denied", dependency.LicenseName),
+			})
+		case len(policy.Allowed) != 0 && (dependency.LicenseName == "" || dependency.LicenseName == UnknownLicense):
+			violations = append(violations, Violation{
+				Dependency: dependency,
+				Reason:     "license could not be detected",
+			})
+		case len(policy.Allowed) != 0 && !containsLicense(policy.Allowed, dependency.LicenseName):
+			violations = append(violations, Violation{
+				Dependency: dependency,
+				Reason:     fmt.Sprintf("license %s is not allowed", dependency.LicenseName),
+			})
+		}
+	}
+	return violations
+}
+
+func isExcepted(exceptions []config.LicensePolicyException, dependency Dependency) bool {
+	for _, exception := range exceptions {
+		if !isIgnored(dependency.Name, []string{exception.Module}) {
+			continue
+		}
+		if exception.License == "" || sameLicense(exception.License, dependency.LicenseName) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsLicense(licenses []string, license string) bool {
+	return slices.ContainsFunc(licenses, func(candidate string) bool {
+		return sameLicense(candidate, license)
+	})
+}
+
+// sameLicense returns whether two SPDX identifiers name the same license,
+// treating a deprecated identifier as equal to its current variants.
+func sameLicense(a, b string) bool {
+	return a == b || slices.Contains(deprecatedLicenses[a], b) || slices.Contains(deprecatedLicenses[b], a)
+}
@@ -0,0 +1,76 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package licenses
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+)
+
+func TestCheckPolicy(t *testing.T) {
+	dependencies := []Dependency{
+		{Name: "example.com/mit", LicenseName: "MIT"},
+		{Name: "example.com/gpl", LicenseName: "GPL-3.0"},
+		{Name: "example.com/unknown", LicenseName: UnknownLicense},
+		{Name: "example.com/vendored/mpl", LicenseName: "MPL-2.0"},
+	}
+
+	for name, tt := range map[string]struct {
+		policy   config.LicensePolicy
+		expected []string
+	}{
+		"empty": {
+			expected: []string{},
+		},
+		"denied": {
+			policy: config.LicensePolicy{
+				Denied: []string{"GPL-3.0"},
+			},
+			expected: []string{"example.com/gpl: license GPL-3.0 is denied"},
+		},
+		"denied current identifier": {
+			policy: config.LicensePolicy{
+				Denied: []string{"GPL-3.0-only"},
+			},
+			expected: []string{"example.com/gpl: license GPL-3.0 is denied"},
+		},
+		"allowed": {
+			policy: config.LicensePolicy{
+				Allowed: []string{"MIT", "MPL-2.0"},
+			},
+			expected: []string{
+				"example.com/gpl: license GPL-3.0 is not allowed",
+				"example.com/unknown: license could not be detected",
+			},
+		},
+		"allowed unknown": {
+			policy: config.LicensePolicy{
+				Allowed: []string{"MIT", "MPL-2.0", "GPL-3.0-or-later", UnknownLicense},
+			},
+			expected: []string{"example.com/unknown: license could not be detected"},
+		},
+		"exceptions": {
+			policy: config.LicensePolicy{
+				Allowed: []string{"MIT"},
+				Exceptions: []config.LicensePolicyException{
+					{Module: "example.com/unknown"},
+					{Module: "example.com/vendored", License: "MPL-2.0"},
+					{Module: "example.com/gpl", License: "LGPL-3.0"},
+				},
+			},
+			expected: []string{"example.com/gpl: license GPL-3.0 is not allowed"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			actual := []string{}
+			for _, violation := range CheckPolicy(tt.policy, dependencies) {
+				actual = append(actual, violation.Error())
+			}
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package licenses
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// UnknownLicense is reported for dependencies whose license can't be detected.
+const UnknownLicense = "Unknown"
+
+var (
+	pseudoVersionPattern = regexp.MustCompile(`\d{14}-([0-9a-f]{12})$`)
+	majorVersionPattern  = regexp.MustCompile(`^v\d+$`)
+
+	// classifiers are checked in order, the first one where every
+	// phrase is found in the license text wins.
+	classifiers = []struct {
+		license string
+		phrases []string
+	}{
+		{"MIT", []string{"permission is hereby granted, free of charge"}},
+		{"ISC", []string{"permission to use, copy, modify, and/or distribute this software for any purpose"}},
+		{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+		{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "names of its contributors"}},
+		{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+		{"Apache-2.0", []string{"apache license", "version 2.0"}},
+		{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+		{"LGPL-3.0", []string{"gnu lesser general public license version 3"}},
+		{"LGPL-2.1", []string{"gnu lesser general public license version 2.1"}},
+		{"GPL-3.0", []string{"gnu general public license version 3"}},
+		{"GPL-2.0", []string{"gnu general public license version 2"}},
+		{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+		{"CC0-1.0", []string{"creative commons", "cc0"}},
+	}
+
+	//go:embed report.md.tpl
+	defaultReportTemplate string
+)
+
+// Dependency is a third-party module and its detected license.
+type Dependency struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	LicenseName string `json:"license"`
+	LicenseURL  string `json:"licenseURL"`
+}
+
+type goModule struct {
+	Path    string
+	Version string
+	Main    bool
+	Dir     string
+	Replace *goModule
+}
+
+type goPackage struct {
+	Standard bool
+	Module   *goModule
+}
+
+// Dependencies returns all of the third-party modules imported by the
+// non-test packages of the Go module in the given directory, skipping any
+// whose module path is prefixed by an entry in ignore.
+func Dependencies(ctx context.Context, directory string, ignore []string) ([]Dependency, error) {
+	var stderr bytes.Buffer
+	list := exec.CommandContext(ctx, "go", "list", "-deps", "-json", "./...")
+	list.Dir = directory
+	list.Stderr = &stderr
+	output, err := list.Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing packages: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	modules := map[string]*goModule{}
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var pkg goPackage
+		if err := decoder.Decode(&pkg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("decoding packages: %w", err)
+		}
+
+		if pkg.Standard || pkg.Module == nil || pkg.Module.Main || isIgnored(pkg.Module.Path, ignore) {
+			continue
+		}
+		modules[pkg.Module.Path] = pkg.Module
+	}
+
+	dependencies := []Dependency{}
+	for _, module := range modules {
+		dependencies = append(dependencies, dependencyFor(module))
+	}
+	slices.SortFunc(dependencies, func(a, b Dependency) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return dependencies, nil
+}
+
+// Classify returns the SPDX identifier of the given license text, or
+// UnknownLicense if it can't be determined.
+func Classify(text string) string {
+	text = strings.Join(strings.Fields(strings.ToLower(text)), " ")
+	for _, classifier := range classifiers {
+		matches := true
+		for _, phrase := range classifier.phrases {
+			if !strings.Contains(text, phrase) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return classifier.license
+		}
+	}
+	return UnknownLicense
+}
+
+// WriteMarkdown renders the dependencies with the given go-licenses
+// compatible template, falling back to a default template if it's empty.
+func WriteMarkdown(w io.Writer, reportTemplate string, dependencies []Dependency) error {
+	if reportTemplate == "" {
+		reportTemplate = defaultReportTemplate
+	}
+
+	tmpl, err := template.New("report").Parse(reportTemplate)
+	if err != nil {
+		return fmt.Errorf("parsing report template: %w", err)
+	}
+	return tmpl.Execute(w, dependencies)
+}
+
+// WriteJSON writes the dependencies as a JSON array.
+func WriteJSON(w io.Writer, dependencies []Dependency) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(dependencies)
+}
+
+// WriteCSV writes the dependencies as CSV rows with a header.
+func WriteCSV(w io.Writer, dependencies []Dependency) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"name", "version", "license", "license_url"}); err != nil {
+		return err
+	}
+	for _, dependency := range dependencies {
+		if err := writer.Write([]string{dependency.Name, dependency.Version, dependency.LicenseName, dependency.LicenseURL}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func dependencyFor(module *goModule) Dependency {
+	source := module
+	if module.Replace != nil {
+		source = module.Replace
+	}
+
+	dependency := Dependency{
+		Name:        module.Path,
+		Version:     source.Version,
+		LicenseName: UnknownLicense,
+		LicenseURL:  UnknownLicense,
+	}
+
+	file, text := findLicense(source.Dir)
+	if file == "" {
+		return dependency
+	}
+
+	dependency.LicenseName = Classify(text)
+	dependency.LicenseURL = licenseURL(source.Path, source.Version, file)
+	return dependency
+}
+
+// findLicense returns the name and contents of the license file at
+// the root of the given module directory.
+func findLicense(directory string) (string, string) {
+	if directory == "" {
+		return "", ""
+	}
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return "", ""
+	}
+
+	for _, entry := range entries {
+		name := strings.ToLower(entry.Name())
+		if entry.IsDir() || !(strings.HasPrefix(name, "licen") || strings.HasPrefix(name, "copying")) {
+			continue
+		}
+
+		data, err := os.ReadFile(path.Join(directory, entry.Name()))
+		if err != nil {
+			continue
+		}
+		return entry.Name(), string(data)
+	}
+
+	return "", ""
+}
+
+// licenseURL returns a browsable URL for the license file of the
+// module at the given version.
+func licenseURL(module, version, file string) string {
+	if version == "" {
+		return file
+	}
+
+	elements := strings.Split(module, "/")
+	switch {
+	case len(elements) >= 3 && elements[0] == "github.com":
+		subdirectory := elements[3:]
+		if len(subdirectory) > 0 && majorVersionPattern.MatchString(subdirectory[len(subdirectory)-1]) {
+			subdirectory = subdirectory[:len(subdirectory)-1]
+		}
+
+		ref := strings.TrimSuffix(version, "+incompatible")
+		if matches := pseudoVersionPattern.FindStringSubmatch(ref); matches != nil {
+			ref = matches[1]
+		} else if len(subdirectory) > 0 {
+			ref = path.Join(path.Join(subdirectory...), ref)
+		}
+
+		return "https://github.com/" + path.Join(elements[1], elements[2], "blob", ref, path.Join(subdirectory...), file)
+	case len(elements) == 3 && elements[0] == "golang.org" && elements[1] == "x":
+		return "https://cs.opensource.google/go/x/" + elements[2] + "/+/" + version + ":" + file
+	default:
+		return "https://pkg.go.dev/" + module + "@" + version + "?tab=licenses"
+	}
+}
+
+func isIgnored(module string, ignore []string) bool {
+	for _, prefix := range ignore {
+		if module == prefix || strings.HasPrefix(module, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
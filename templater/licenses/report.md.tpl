@@ -2,7 +2,7 @@
 
 <!--
 
-This list is auto generated with go-licenses
+This list is auto generated with templater license report
 
 run `task generate-third-party-licenses`
 
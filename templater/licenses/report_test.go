@@ -0,0 +1,88 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassify(t *testing.T) {
+	for _, identifier := range Supported() {
+		t.Run(identifier, func(t *testing.T) {
+			text, err := Render(identifier, "Org", 2025)
+			require.NoError(t, err)
+
+			expected := identifier
+			switch identifier {
+			case "GPL-3.0-only", "GPL-3.0-or-later":
+				expected = "GPL-3.0"
+			}
+			require.Equal(t, expected, Classify(text))
+		})
+	}
+
+	require.Equal(t, UnknownLicense, Classify("All rights reserved."))
+}
+
+func TestLicenseURL(t *testing.T) {
+	for _, tt := range []struct {
+		module   string
+		version  string
+		expected string
+	}{
+		{"github.com/cqroot/prompt", "v0.9.4", "https://github.com/cqroot/prompt/blob/v0.9.4/LICENSE"},
+		{"github.com/aymanbagabas/go-osc52/v2", "v2.0.1", "https://github.com/aymanbagabas/go-osc52/blob/v2.0.1/LICENSE"},
+		{"github.com/charmbracelet/x/ansi", "v0.1.1", "https://github.com/charmbracelet/x/blob/ansi/v0.1.1/ansi/LICENSE"},
+		{"github.com/containerd/console", "v1.0.4-0.20230313162750-1ae8d489ac81", "https://github.com/containerd/console/blob/1ae8d489ac81/LICENSE"},
+		{"golang.org/x/crypto", "v0.36.0", "https://cs.opensource.google/go/x/crypto/+/v0.36.0:LICENSE"},
+		{"gopkg.in/yaml.v3", "v3.0.1", "https://pkg.go.dev/gopkg.in/yaml.v3@v3.0.1?tab=licenses"},
+	} {
+		t.Run(tt.module, func(t *testing.T) {
+			require.Equal(t, tt.expected, licenseURL(tt.module, tt.version, "LICENSE"))
+		})
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	root := t.TempDir()
+
+	mit, err := Render("MIT", "Org", 2025)
+	require.NoError(t, err)
+
+	files := map[string]string{
+		"main/go.mod":        "module example.com/main\n\ngo 1.23\n\nrequire (\n\texample.com/dep v0.0.0\n\texample.com/ignored v0.0.0\n)\n\nreplace example.com/dep => ../dep\n\nreplace example.com/ignored => ../ignored\n",
+		"main/main.go":       "package main\n\nimport (\n\t_ \"example.com/dep\"\n\t_ \"example.com/ignored\"\n)\n\nfunc main() {}\n",
+		"dep/go.mod":         "module example.com/dep\n\ngo 1.23\n",
+		"dep/dep.go":         "package dep\n",
+		"dep/LICENSE":        mit,
+		"ignored/go.mod":     "module example.com/ignored\n\ngo 1.23\n",
+		"ignored/ignored.go": "package ignored\n",
+	}
+	for name, content := range files {
+		fullPath := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+		require.NoError(t, os.WriteFile(fullPath, []byte(content), 0644))
+	}
+
+	t.Setenv("GOFLAGS", "-mod=mod")
+	t.Setenv("GOWORK", "off")
+
+	dependencies, err := Dependencies(context.Background(), filepath.Join(root, "main"), []string{"example.com/ignored"})
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{{
+		Name:        "example.com/dep",
+		LicenseName: "MIT",
+		LicenseURL:  "LICENSE",
+	}}, dependencies)
+
+	var buffer bytes.Buffer
+	require.NoError(t, WriteCSV(&buffer, dependencies))
+	require.Equal(t, "name,version,license,license_url\nexample.com/dep,,MIT,LICENSE\n", buffer.String())
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/cqroot/prompt/input"
 
 	"github.com/andrewstucki/actions-testing/templater/config"
+	"github.com/andrewstucki/actions-testing/templater/licenses"
+	"github.com/andrewstucki/actions-testing/templater/templates"
 )
 
 type configFunc func(cfg *config.ConfigFile, field string) error
@@ -45,6 +47,7 @@ type field struct {
 	defaultValue   string
 	defaultValueFn func(cfg *config.ConfigFile) string
 	isNumber       bool
+	skip           func(cfg *config.ConfigFile) bool
 	validator      configFunc
 }
 
@@ -55,8 +58,35 @@ var initPrompts = []field{
 	{prompt: "Github organization", validator: func(cfg *config.ConfigFile, field string) error {
 		return setFieldNotEmpty(&cfg.GithubInfo.Organization, "organization name", field)
 	}},
-	{prompt: "License", defaultValue: "MIT", validator: func(cfg *config.ConfigFile, field string) error {
-		return setFieldDefault(&cfg.License.License, "MIT", field)
+	{prompt: "Language", defaultValue: string(templates.LanguageGo), validator: func(cfg *config.ConfigFile, field string) error {
+		if strings.TrimSpace(field) == "" {
+			field = string(templates.LanguageGo)
+		}
+		language, err := templates.ParseLanguage(field)
+		if err != nil {
+			return err
+		}
+		cfg.Language = string(language)
+		return nil
+	}},
+	{prompt: "Project scaffold (main, cli, library, service)", defaultValue: string(templates.ScaffoldMain), skip: func(cfg *config.ConfigFile) bool {
+		return cfg.Language != string(templates.LanguageGo)
+	}, validator: func(cfg *config.ConfigFile, field string) error {
+		if strings.TrimSpace(field) == "" {
+			field = string(templates.ScaffoldMain)
+		}
+		scaffold, err := templates.ParseScaffold(field)
+		if err != nil {
+			return err
+		}
+		cfg.Projects[0].Scaffold = string(scaffold)
+		return nil
+	}},
+	{prompt: "License (SPDX identifier)", defaultValue: "MIT", validator: func(cfg *config.ConfigFile, field string) error {
+		if err := setFieldDefault(&cfg.License.License, "MIT", field); err != nil {
+			return err
+		}
+		return licenses.Validate(cfg.License.License)
 	}},
 	{prompt: "Copyright holder", defaultValueFn: func(cfg *config.ConfigFile) string { return cfg.GithubInfo.Organization }, validator: func(cfg *config.ConfigFile, field string) error {
 		return setFieldDefault(&cfg.License.Copyright, cfg.GithubInfo.Organization, field)
@@ -70,10 +100,14 @@ var initPrompts = []field{
 }
 
 func Run() (*config.ConfigFile, error) {
-	cfg := &config.ConfigFile{}
+	cfg := &config.ConfigFile{Projects: []config.ProjectInfo{{}}}
 	prompter := prompt.New()
 
 	for _, field := range initPrompts {
+		if field.skip != nil && field.skip(cfg) {
+			continue
+		}
+
 		opts := []input.Option{
 			input.WithHelp(true),
 			input.WithValidateFunc(wrapConfigFunc(cfg, field.validator)),
@@ -101,57 +135,54 @@ func Run() (*config.ConfigFile, error) {
 	cfg.Backports.Mappings = map[string]string{
 		"^v(\\d+).(\\d+).\\d+$": "v$1.$2.x",
 	}
-	cfg.Projects = append(cfg.Projects, config.ProjectInfo{
-		Name:      cfg.GithubInfo.Repository,
-		Changelog: "CHANGELOG.md",
-	})
+	cfg.Projects[0].Name = cfg.GithubInfo.Repository
+	cfg.Projects[0].Changelog = "CHANGELOG.md"
 
 	return cfg, nil
 }
 
-func RunSecretSync(cfg config.ConfigFile) ([]*config.Secret, bool, error) {
-	prompter := prompt.New()
-
-	values := []string{
-		cfg.Backports.Bot.TokenVariable,
-		"SLACK_WEBHOOK_URL",
+// AskDeclaredSecret prompts for the value of a declared secret without
+// echoing it, required secrets can't be left empty.
+func AskDeclaredSecret(info config.SecretInfo) (string, error) {
+	askPrompt := fmt.Sprintf("Value for %s", info.Name)
+	if info.Description != "" {
+		askPrompt = fmt.Sprintf("%s (%s)", askPrompt, info.Description)
 	}
-
-	secrets := []*config.Secret{}
-	names := []string{}
-	getSecret := func(name string) error {
-		askPrompt := fmt.Sprintf("Value for %s", name)
-		response, err := prompter.Ask(askPrompt).Input("", input.WithHelp(true), input.WithEchoMode(input.EchoPassword))
-		if err != nil {
-			return err
-		}
-		response = strings.TrimSpace(response)
-		if response != "" {
-			secrets = append(secrets, &config.Secret{
-				Name:  name,
-				Value: strings.TrimSpace(response),
-			})
-			names = append(names, name)
-		}
-		return nil
+	if !info.Required {
+		askPrompt += ", leave empty to skip"
 	}
 
-	for _, value := range values {
-		if err := getSecret(value); err != nil {
-			return nil, false, err
-		}
+	options := []input.Option{input.WithHelp(true), input.WithEchoMode(input.EchoPassword)}
+	if info.Required {
+		options = append(options, input.WithValidateFunc(func(value string) error {
+			if strings.TrimSpace(value) == "" {
+				return fmt.Errorf("%s is required", info.Name)
+			}
+			return nil
+		}))
 	}
 
-	if len(secrets) == 0 {
-		return nil, false, nil
+	response, err := prompt.New().Ask(askPrompt).Input("", options...)
+	if err != nil {
+		return "", err
 	}
+	return strings.TrimSpace(response), nil
+}
 
-	message := fmt.Sprintf("Do you wish to set %s (only a \"yes\" will continue)", strings.Join(names, " and "))
-
-	value, err := prompter.Ask(message).Input("", input.WithHelp(true))
+// AskSecret prompts for the value of the given secret without echoing it.
+func AskSecret(name string) (string, error) {
+	response, err := prompt.New().Ask(fmt.Sprintf("Value for %s", name)).Input("", input.WithHelp(true), input.WithEchoMode(input.EchoPassword))
 	if err != nil {
-		return nil, false, err
+		return "", err
 	}
+	return strings.TrimSpace(response), nil
+}
 
-	return secrets, strings.TrimSpace(value) == "yes", nil
+// Confirm asks the given question, only a "yes" answer confirms it.
+func Confirm(message string) (bool, error) {
+	value, err := prompt.New().Ask(fmt.Sprintf("%s (only a \"yes\" will continue)", message)).Input("", input.WithHelp(true))
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(value) == "yes", nil
 }
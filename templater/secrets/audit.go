@@ -0,0 +1,197 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+)
+
+var (
+	expressionPattern = regexp.MustCompile(`\$\{\{(.*?)\}\}`)
+	referencePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\bsecrets\.([A-Za-z_][A-Za-z0-9_]*)`),
+		regexp.MustCompile(`\bsecrets\[\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]\s*\]`),
+	}
+)
+
+// Existing is a secret that is set on GitHub and available to the
+// repository.
+type Existing struct {
+	Name      string
+	Scope     string
+	UpdatedAt time.Time
+}
+
+// Finding is a secret reported by an audit.
+type Finding struct {
+	Name      string     `json:"name"`
+	Workflows []string   `json:"workflows,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// AuditReport compares the secrets a repository has with the ones its
+// workflows need.
+type AuditReport struct {
+	// Missing secrets are referenced by a workflow or declared as required
+	// but aren't set.
+	Missing []Finding `json:"missing"`
+	// Unused secrets are set on the repository but no workflow references
+	// them.
+	Unused []Finding `json:"unused"`
+	// Stale secrets are set on the repository but haven't been updated
+	// since the audit's cutoff.
+	Stale []Finding `json:"stale"`
+}
+
+// WorkflowReferences returns the secrets referenced by expressions in the
+// workflows of the given directory, mapped to the workflows referencing
+// them. The built-in GITHUB_TOKEN is ignored.
+func WorkflowReferences(directory string) (map[string][]string, error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string][]string{}, nil
+		}
+		return nil, err
+	}
+
+	references := map[string][]string{}
+	for _, entry := range entries {
+		extension := filepath.Ext(entry.Name())
+		if entry.IsDir() || (extension != ".yml" && extension != ".yaml") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(directory, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, expression := range expressionPattern.FindAllStringSubmatch(string(data), -1) {
+			for _, pattern := range referencePatterns {
+				for _, match := range pattern.FindAllStringSubmatch(expression[1], -1) {
+					name := match[1]
+					if strings.EqualFold(name, builtinToken) || slices.Contains(references[name], entry.Name()) {
+						continue
+					}
+					references[name] = append(references[name], entry.Name())
+				}
+			}
+		}
+	}
+	return references, nil
+}
+
+// Audit compares the existing secrets with the referenced and declared
+// ones. Repository secrets last updated before staleBefore are reported as
+// stale, a zero staleBefore disables the check. Environment and Dependabot
+// secrets aren't listed by GitHub with the repository's Actions secrets, so
+// they're never reported as missing.
+func Audit(declared []config.SecretInfo, references map[string][]string, existing []Existing, staleBefore time.Time) AuditReport {
+	report := AuditReport{Missing: []Finding{}, Unused: []Finding{}, Stale: []Finding{}}
+
+	set := map[string]struct{}{}
+	for _, secret := range existing {
+		set[strings.ToUpper(secret.Name)] = struct{}{}
+	}
+
+	needed := map[string][]string{}
+	for name, workflows := range references {
+		needed[name] = workflows
+	}
+	for _, secret := range declared {
+		switch {
+		case secret.Scope == ScopeEnvironment || secret.Dependabot:
+			delete(needed, secret.Name)
+		case secret.Required:
+			needed[secret.Name] = slices.Compact(slices.Sorted(slices.Values(append(slices.Clone(needed[secret.Name]), secret.Workflows...))))
+		}
+	}
+
+	for name, workflows := range needed {
+		if _, ok := set[strings.ToUpper(name)]; !ok {
+			report.Missing = append(report.Missing, Finding{Name: name, Workflows: workflows})
+		}
+	}
+
+	referenced := map[string]struct{}{}
+	for name := range references {
+		referenced[strings.ToUpper(name)] = struct{}{}
+	}
+	for _, secret := range existing {
+		if secret.Scope != ScopeRepository {
+			continue
+		}
+		if _, ok := referenced[strings.ToUpper(secret.Name)]; !ok {
+			report.Unused = append(report.Unused, Finding{Name: secret.Name})
+		}
+		if !staleBefore.IsZero() && !secret.UpdatedAt.IsZero() && secret.UpdatedAt.Before(staleBefore) {
+			updatedAt := secret.UpdatedAt
+			report.Stale = append(report.Stale, Finding{Name: secret.Name, UpdatedAt: &updatedAt})
+		}
+	}
+
+	for _, findings := range [][]Finding{report.Missing, report.Unused, report.Stale} {
+		slices.SortFunc(findings, func(a, b Finding) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+	}
+	return report
+}
+
+// Write renders the report as human readable text.
+func (r AuditReport) Write(w io.Writer) error {
+	if len(r.Missing) == 0 && len(r.Unused) == 0 && len(r.Stale) == 0 {
+		_, err := fmt.Fprintln(w, "No missing, unused or stale secrets.")
+		return err
+	}
+
+	sections := []struct {
+		title    string
+		findings []Finding
+	}{
+		{"Missing secrets:", r.Missing},
+		{"Unused secrets:", r.Unused},
+		{"Stale secrets:", r.Stale},
+	}
+	for _, section := range sections {
+		if len(section.findings) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintln(w, section.title); err != nil {
+			return err
+		}
+		for _, finding := range section.findings {
+			line := "  " + finding.Name
+			switch {
+			case len(finding.Workflows) != 0:
+				line += fmt.Sprintf(" (used by %s)", strings.Join(finding.Workflows, ", "))
+			case finding.UpdatedAt != nil:
+				line += fmt.Sprintf(" (last updated %s)", finding.UpdatedAt.Format(time.DateOnly))
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteJSON renders the report as JSON.
+func (r AuditReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
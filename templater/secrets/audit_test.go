@@ -0,0 +1,119 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package secrets
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+	"github.com/andrewstucki/actions-testing/templater/templates"
+)
+
+func TestWorkflowReferences(t *testing.T) {
+	directory := t.TempDir()
+
+	workflows := map[string]string{
+		"deploy.yml": `
+jobs:
+  deploy:
+    steps:
+      - env:
+          KEY: ${{ secrets.DEPLOY_KEY }}
+          TOKEN: ${{ secrets['NPM_TOKEN'] || secrets.GITHUB_TOKEN }}
+      - run: echo "secrets.NOT_AN_EXPRESSION"
+`,
+		"release.yaml": `
+jobs:
+  release:
+    steps:
+      - with:
+          token: ${{ secrets.NPM_TOKEN }}
+          again: ${{ secrets.NPM_TOKEN }}
+`,
+		"README.md": "${{ secrets.IGNORED }}",
+	}
+	for name, content := range workflows {
+		require.NoError(t, os.WriteFile(filepath.Join(directory, name), []byte(content), 0644))
+	}
+
+	references, err := WorkflowReferences(directory)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{
+		"DEPLOY_KEY": {"deploy.yml"},
+		"NPM_TOKEN":  {"deploy.yml", "release.yaml"},
+	}, references)
+}
+
+func TestWorkflowReferencesRendered(t *testing.T) {
+	directory := t.TempDir()
+
+	require.NoError(t, (&templates.Renderer{IgnoreExecutable: true}).RenderTo(directory, templates.TemplateInfo{
+		Organization:        "org",
+		Repository:          "repo",
+		License:             "MIT",
+		Backports:           true,
+		BackportBotTokenVar: "BOT_TOKEN",
+	}))
+
+	references, err := WorkflowReferences(filepath.Join(directory, ".github", "workflows"))
+	require.NoError(t, err)
+
+	declared, err := Declared(config.ConfigFile{Backports: config.BackportInfo{Bot: config.BotInfo{TokenVariable: "BOT_TOKEN"}}})
+	require.NoError(t, err)
+	for _, secret := range declared {
+		require.Equal(t, secret.Workflows, references[secret.Name], secret.Name)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	report := Audit(
+		[]config.SecretInfo{
+			{Name: "SLACK_WEBHOOK_URL", Scope: ScopeRepository, Required: true, Workflows: []string{"pending-prs.yml"}},
+			{Name: "DEPLOY_KEY", Scope: ScopeEnvironment, Environment: "production"},
+		},
+		map[string][]string{
+			"BOT_TOKEN":  {"backport.yml"},
+			"DEPLOY_KEY": {"deploy.yml"},
+			"NPM_TOKEN":  {"release.yml"},
+		},
+		[]Existing{
+			{Name: "NPM_TOKEN", Scope: ScopeOrganization, UpdatedAt: now.AddDate(-2, 0, 0)},
+			{Name: "OLD_TOKEN", Scope: ScopeRepository, UpdatedAt: now.AddDate(-1, 0, 0)},
+		},
+		now.AddDate(0, -3, 0),
+	)
+
+	old := now.AddDate(-1, 0, 0)
+	require.Equal(t, AuditReport{
+		Missing: []Finding{
+			{Name: "BOT_TOKEN", Workflows: []string{"backport.yml"}},
+			{Name: "SLACK_WEBHOOK_URL", Workflows: []string{"pending-prs.yml"}},
+		},
+		Unused: []Finding{{Name: "OLD_TOKEN"}},
+		Stale:  []Finding{{Name: "OLD_TOKEN", UpdatedAt: &old}},
+	}, report)
+
+	var buffer bytes.Buffer
+	require.NoError(t, report.Write(&buffer))
+	require.Equal(t, `Missing secrets:
+  BOT_TOKEN (used by backport.yml)
+  SLACK_WEBHOOK_URL (used by pending-prs.yml)
+Unused secrets:
+  OLD_TOKEN
+Stale secrets:
+  OLD_TOKEN (last updated 2025-01-01)
+`, buffer.String())
+
+	buffer.Reset()
+	require.NoError(t, Audit(nil, nil, nil, time.Time{}).Write(&buffer))
+	require.Equal(t, "No missing, unused or stale secrets.\n", buffer.String())
+}
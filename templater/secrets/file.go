@@ -0,0 +1,166 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"gopkg.in/yaml.v3"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+)
+
+// DefaultFile is where the encrypted secrets file is kept, relative to the
+// configuration file, unless configured otherwise.
+const DefaultFile = ".github/secrets.age"
+
+// FileEnabled returns whether the configuration keeps secret values in an
+// encrypted file.
+func FileEnabled(info config.SecretsFileInfo) bool {
+	return info.Path != "" || len(info.Recipients) != 0
+}
+
+// FilePath returns the path of the encrypted secrets file, relative paths
+// are resolved against the given directory.
+func FilePath(info config.SecretsFileInfo, directory string) string {
+	path := info.Path
+	if path == "" {
+		path = DefaultFile
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(directory, path)
+}
+
+// IdentityFile returns the path of the age identity used to decrypt the
+// secrets file, defaulting to templater/age.key in the user's configuration
+// directory.
+func IdentityFile(info config.SecretsFileInfo) (string, error) {
+	if info.Identity != "" {
+		if rest, ok := strings.CutPrefix(info.Identity, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", fmt.Errorf("getting home directory: %w", err)
+			}
+			return filepath.Join(home, rest), nil
+		}
+		return info.Identity, nil
+	}
+
+	directory, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("getting configuration directory: %w", err)
+	}
+	return filepath.Join(directory, "templater", "age.key"), nil
+}
+
+// FileKey returns the key a secret's value is stored under in the secrets
+// file, environment secrets are prefixed with their environment since the
+// same name can be used across environments.
+func FileKey(secret config.SecretInfo) string {
+	if secret.Scope == ScopeEnvironment {
+		return secret.Environment + "/" + secret.Name
+	}
+	return secret.Name
+}
+
+// ReadFile decrypts the secrets file with the identities in identityFile,
+// returning the stored values by key.
+func ReadFile(path, identityFile string) (map[string]string, error) {
+	keys, err := os.Open(identityFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading identity: %w", err)
+	}
+	defer keys.Close()
+
+	identities, err := age.ParseIdentities(keys)
+	if err != nil {
+		return nil, fmt.Errorf("parsing identity %q: %w", identityFile, err)
+	}
+
+	encrypted, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer encrypted.Close()
+
+	reader, err := age.Decrypt(armor.NewReader(encrypted), identities...)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %q: %w", path, err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %q: %w", path, err)
+	}
+
+	return ParseValues(data)
+}
+
+// WriteFile encrypts the values for the given age recipients and writes
+// them armored so the file can be reviewed in pull requests. Empty values
+// are left out.
+func WriteFile(path string, values map[string]string, recipients []string) error {
+	if len(recipients) == 0 {
+		return errors.New("at least one recipient is required to encrypt the secrets file")
+	}
+
+	parsed := []age.Recipient{}
+	for _, recipient := range recipients {
+		key, err := age.ParseX25519Recipient(recipient)
+		if err != nil {
+			return fmt.Errorf("parsing recipient %q: %w", recipient, err)
+		}
+		parsed = append(parsed, key)
+	}
+
+	stored := map[string]string{}
+	for key, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			stored[key] = value
+		}
+	}
+	data, err := yaml.Marshal(stored)
+	if err != nil {
+		return err
+	}
+
+	var buffer bytes.Buffer
+	armored := armor.NewWriter(&buffer)
+	writer, err := age.Encrypt(armored, parsed...)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	if err := armored.Close(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buffer.Bytes(), 0644)
+}
+
+// ParseValues parses the decrypted contents of a secrets file.
+func ParseValues(data []byte) (map[string]string, error) {
+	values := map[string]string{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("parsing secrets: %w", err)
+	}
+	return values, nil
+}
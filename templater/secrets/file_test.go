@@ -0,0 +1,65 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+)
+
+func TestFile(t *testing.T) {
+	directory := t.TempDir()
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	identityFile := filepath.Join(directory, "age.key")
+	require.NoError(t, os.WriteFile(identityFile, []byte("# test key\n"+identity.String()+"\n"), 0600))
+
+	path := FilePath(config.SecretsFileInfo{}, directory)
+	require.Equal(t, filepath.Join(directory, ".github", "secrets.age"), path)
+
+	require.NoError(t, WriteFile(path, map[string]string{
+		"BOT_TOKEN":             "token",
+		"production/DEPLOY_KEY": "key\n",
+		"SLACK_WEBHOOK_URL":     "",
+	}, []string{identity.Recipient().String()}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(data), armor.Header))
+	require.NotContains(t, string(data), "token")
+
+	values, err := ReadFile(path, identityFile)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"BOT_TOKEN":             "token",
+		"production/DEPLOY_KEY": "key",
+	}, values)
+
+	require.Equal(t, "production/DEPLOY_KEY", FileKey(config.SecretInfo{Name: "DEPLOY_KEY", Scope: ScopeEnvironment, Environment: "production"}))
+	require.Equal(t, "BOT_TOKEN", FileKey(config.SecretInfo{Name: "BOT_TOKEN", Scope: ScopeRepository}))
+
+	t.Run("wrong identity", func(t *testing.T) {
+		other, err := age.GenerateX25519Identity()
+		require.NoError(t, err)
+		otherFile := filepath.Join(directory, "other.key")
+		require.NoError(t, os.WriteFile(otherFile, []byte(other.String()), 0600))
+
+		_, err = ReadFile(path, otherFile)
+		require.ErrorContains(t, err, "decrypting")
+	})
+
+	t.Run("invalid recipient", func(t *testing.T) {
+		err := WriteFile(path, map[string]string{}, []string{"not-a-key"})
+		require.ErrorContains(t, err, `parsing recipient "not-a-key"`)
+	})
+}
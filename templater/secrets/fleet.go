@@ -0,0 +1,123 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+)
+
+// SetFunc sets a secret's value on a single repository of the given host,
+// empty for github.com.
+type SetFunc func(ctx context.Context, host, organization, repo string) error
+
+// Rotation is the outcome of rotating a secret on a fleet repository.
+type Rotation struct {
+	Repository string
+	Err        error
+}
+
+// LoadFleet reads a fleet file listing repositories as organization/name.
+func LoadFleet(path string) (config.FleetFile, error) {
+	var fleet config.FleetFile
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fleet, err
+	}
+	if err := yaml.Unmarshal(data, &fleet); err != nil {
+		return fleet, fmt.Errorf("parsing fleet %q: %w", path, err)
+	}
+
+	if len(fleet.Repositories) == 0 {
+		return fleet, fmt.Errorf("fleet %q has no repositories", path)
+	}
+	seen := map[string]struct{}{}
+	for _, repository := range fleet.Repositories {
+		if _, _, err := splitRepository(repository.Name); err != nil {
+			return fleet, fmt.Errorf("invalid fleet repository %q: %w", repository.Name, err)
+		}
+		key := strings.ToLower(repository.Name)
+		if _, ok := seen[key]; ok {
+			return fleet, fmt.Errorf("fleet repository %q is listed more than once", repository.Name)
+		}
+		seen[key] = struct{}{}
+	}
+	return fleet, nil
+}
+
+// SaveFleet writes the fleet file back along with the recorded rotations.
+func SaveFleet(path string, fleet config.FleetFile) error {
+	data, err := yaml.Marshal(fleet)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Rotate sets a secret on every repository of the fleet, continuing past
+// failures so a single broken repository doesn't block the rest. A
+// repository's host overrides the host of the fleet. The time of the
+// rotation is recorded on each repository it succeeded for.
+func Rotate(ctx context.Context, fleet *config.FleetFile, name string, set SetFunc, now time.Time) []Rotation {
+	rotations := []Rotation{}
+	for i := range fleet.Repositories {
+		repository := &fleet.Repositories[i]
+
+		organization, repo, err := splitRepository(repository.Name)
+		if err == nil {
+			host := repository.Host
+			if host == "" {
+				host = fleet.Host
+			}
+			err = set(ctx, host, organization, repo)
+		}
+		rotations = append(rotations, Rotation{Repository: repository.Name, Err: err})
+		if err != nil {
+			continue
+		}
+
+		if repository.Rotated == nil {
+			repository.Rotated = map[string]time.Time{}
+		}
+		repository.Rotated[name] = now.UTC()
+	}
+	return rotations
+}
+
+// WriteRotations renders the rotations as a table, one row per repository.
+func WriteRotations(w io.Writer, rotations []Rotation) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(table, "REPOSITORY\tSTATUS\tDETAILS"); err != nil {
+		return err
+	}
+	for _, rotation := range rotations {
+		status, details := StatusUpdated, "value rotated"
+		if rotation.Err != nil {
+			status, details = StatusFailed, rotation.Err.Error()
+		}
+		if _, err := fmt.Fprintf(table, "%s\t%s\t%s\n", rotation.Repository, status, details); err != nil {
+			return err
+		}
+	}
+	return table.Flush()
+}
+
+func splitRepository(name string) (string, string, error) {
+	organization, repo, ok := strings.Cut(name, "/")
+	if !ok || organization == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", errors.New("must be in the form organization/repository")
+	}
+	return organization, repo, nil
+}
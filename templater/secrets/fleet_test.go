@@ -0,0 +1,76 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFleet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fleet.yaml")
+	now := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	require.NoError(t, os.WriteFile(path, []byte(`host: github.example.com
+repositories:
+- name: org/one
+  rotated:
+    BOT_TOKEN: 2026-01-01T00:00:00Z
+- name: org/two
+- name: other/three
+  host: github.com
+`), 0644))
+
+	fleet, err := LoadFleet(path)
+	require.NoError(t, err)
+
+	set := []string{}
+	rotations := Rotate(context.Background(), &fleet, "BOT_TOKEN", func(ctx context.Context, host, organization, repo string) error {
+		set = append(set, host+"/"+organization+"/"+repo)
+		if repo == "two" {
+			return errors.New("404 Not Found")
+		}
+		return nil
+	}, now)
+	require.Equal(t, []string{"github.example.com/org/one", "github.example.com/org/two", "github.com/other/three"}, set)
+	require.Equal(t, []Rotation{
+		{Repository: "org/one"},
+		{Repository: "org/two", Err: errors.New("404 Not Found")},
+		{Repository: "other/three"},
+	}, rotations)
+
+	require.NoError(t, SaveFleet(path, fleet))
+	fleet, err = LoadFleet(path)
+	require.NoError(t, err)
+	require.Equal(t, now, fleet.Repositories[0].Rotated["BOT_TOKEN"])
+	require.NotContains(t, fleet.Repositories[1].Rotated, "BOT_TOKEN")
+	require.Equal(t, now, fleet.Repositories[2].Rotated["BOT_TOKEN"])
+
+	var buffer bytes.Buffer
+	require.NoError(t, WriteRotations(&buffer, rotations))
+	require.Equal(t, `REPOSITORY   STATUS   DETAILS
+org/one      updated  value rotated
+org/two      failed   404 Not Found
+other/three  updated  value rotated
+`, buffer.String())
+
+	t.Run("invalid", func(t *testing.T) {
+		for contents, message := range map[string]string{
+			"repositories: []\n":                            "has no repositories",
+			"repositories:\n- name: org\n":                  `invalid fleet repository "org"`,
+			"repositories:\n- name: org/a\n- name: ORG/A\n": `fleet repository "ORG/A" is listed more than once`,
+		} {
+			require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+			_, err := LoadFleet(path)
+			require.ErrorContains(t, err, message)
+		}
+	})
+}
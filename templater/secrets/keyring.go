@@ -0,0 +1,58 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package secrets
+
+import (
+	"errors"
+	"time"
+
+	"github.com/zalando/go-keyring"
+)
+
+// DefaultKeyringTimeout bounds keyring lookups unless configured otherwise,
+// they can hang when no keyring daemon is available.
+const DefaultKeyringTimeout = 3 * time.Second
+
+var ErrKeyringNotFound = errors.New("secret not found in keyring")
+
+type TimeoutError struct {
+	message string
+}
+
+func (e *TimeoutError) Error() string {
+	return e.message
+}
+
+// KeyringGet looks up a keyring entry, giving up after the timeout or
+// DefaultKeyringTimeout when it's zero. A missing entry is
+// ErrKeyringNotFound.
+//
+// from https://github.com/cli/cli/blob/af4acb380136fd106b38cc0ab404ff975bca9795/internal/keyring/keyring.go#L37C1-L59C2
+func KeyringGet(service, user string, timeout time.Duration) (string, error) {
+	if timeout == 0 {
+		timeout = DefaultKeyringTimeout
+	}
+
+	ch := make(chan struct {
+		val string
+		err error
+	}, 1)
+	go func() {
+		defer close(ch)
+		val, err := keyring.Get(service, user)
+		ch <- struct {
+			val string
+			err error
+		}{val, err}
+	}()
+	select {
+	case res := <-ch:
+		if errors.Is(res.err, keyring.ErrNotFound) {
+			return "", ErrKeyringNotFound
+		}
+		return res.val, res.err
+	case <-time.After(timeout):
+		return "", &TimeoutError{"timeout while trying to get secret from keyring"}
+	}
+}
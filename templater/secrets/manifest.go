@@ -0,0 +1,173 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+)
+
+const (
+	ScopeRepository   = "repo"
+	ScopeEnvironment  = "environment"
+	ScopeOrganization = "org"
+
+	VisibilityAll      = "all"
+	VisibilityPrivate  = "private"
+	VisibilitySelected = "selected"
+
+	builtinToken = "GITHUB_TOKEN"
+)
+
+var (
+	scopes       = []string{ScopeRepository, ScopeEnvironment, ScopeOrganization}
+	visibilities = []string{VisibilityAll, VisibilityPrivate, VisibilitySelected}
+	namePattern  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Declared returns the secrets declared in the configuration, validated
+// and with their scope defaulted, organization secrets default to being
+// visible to selected repositories. Without a secrets section it returns
+// the secrets the templated workflows use.
+func Declared(cfg config.ConfigFile) ([]config.SecretInfo, error) {
+	declared := cfg.Secrets
+	if len(declared) == 0 {
+		declared = defaultSecrets(cfg)
+	}
+
+	secrets := []config.SecretInfo{}
+	seen := map[string]struct{}{}
+	for _, secret := range declared {
+		if secret.Scope == "" {
+			secret.Scope = ScopeRepository
+		}
+		if secret.Scope == ScopeOrganization && secret.Visibility == "" {
+			secret.Visibility = VisibilitySelected
+		}
+		if err := validate(secret); err != nil {
+			return nil, fmt.Errorf("invalid secret %q: %w", secret.Name, err)
+		}
+
+		key := strings.ToUpper(fmt.Sprintf("%s/%s/%t/%s", secret.Scope, secret.Environment, secret.Dependabot, secret.Name))
+		if _, ok := seen[key]; ok {
+			return nil, fmt.Errorf("secret %q is declared more than once", secret.Name)
+		}
+		seen[key] = struct{}{}
+
+		secrets = append(secrets, secret)
+	}
+	return secrets, nil
+}
+
+// Names returns the names of the declared Actions secrets with the given
+// scope, Dependabot secrets are left out.
+func Names(secrets []config.SecretInfo, scope string) []string {
+	names := []string{}
+	for _, secret := range secrets {
+		if secret.Scope == scope && !secret.Dependabot {
+			names = append(names, secret.Name)
+		}
+	}
+	return names
+}
+
+// Target describes where a declared secret is stored.
+func Target(secret config.SecretInfo) string {
+	target := "repository"
+	switch secret.Scope {
+	case ScopeEnvironment:
+		target = fmt.Sprintf("environment %q", secret.Environment)
+	case ScopeOrganization:
+		target = "organization"
+	}
+	if secret.Dependabot {
+		target = "dependabot " + target
+	}
+	return target
+}
+
+func defaultSecrets(cfg config.ConfigFile) []config.SecretInfo {
+	secrets := []config.SecretInfo{}
+	if token := cfg.Backports.Bot.TokenVariable; token != "" && token != builtinToken {
+		secrets = append(secrets, config.SecretInfo{
+			Name:        token,
+			Description: "Token used by the backport bot to open pull requests",
+			Required:    true,
+			Workflows:   []string{"backport.yml"},
+		})
+	}
+	return append(secrets, config.SecretInfo{
+		Name:        "SLACK_WEBHOOK_URL",
+		Description: "Slack webhook notified about pending pull requests",
+		Workflows:   []string{"pending-prs.yml"},
+	})
+}
+
+// ValidateName checks a secret or variable name against the naming rules
+// GitHub enforces.
+func ValidateName(name string) error {
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if !namePattern.MatchString(name) {
+		return errors.New("name must only contain alphanumeric characters or underscores and not start with a number")
+	}
+	if strings.HasPrefix(strings.ToUpper(name), "GITHUB_") {
+		return errors.New("name must not start with GITHUB_")
+	}
+	return nil
+}
+
+func validate(secret config.SecretInfo) error {
+	if err := ValidateName(secret.Name); err != nil {
+		return err
+	}
+
+	if err := validateSource(secret.Source); err != nil {
+		return err
+	}
+
+	for _, workflow := range secret.Workflows {
+		extension := filepath.Ext(workflow)
+		if workflow != filepath.Base(workflow) || (extension != ".yml" && extension != ".yaml") {
+			return fmt.Errorf("workflow %q must be the file name of a workflow in .github/workflows", workflow)
+		}
+	}
+
+	switch secret.Scope {
+	case ScopeEnvironment:
+		if secret.Environment == "" {
+			return errors.New("environment is required for environment secrets")
+		}
+		if secret.Dependabot {
+			return errors.New("dependabot secrets can't be scoped to an environment")
+		}
+	case ScopeRepository, ScopeOrganization:
+		if secret.Environment != "" {
+			return fmt.Errorf("environment is only supported for %s secrets", ScopeEnvironment)
+		}
+	default:
+		return fmt.Errorf("unsupported scope %q, must be one of %v", secret.Scope, scopes)
+	}
+
+	if secret.Scope != ScopeOrganization {
+		if secret.Visibility != "" || len(secret.Repositories) != 0 {
+			return fmt.Errorf("visibility and repositories are only supported for %s secrets", ScopeOrganization)
+		}
+		return nil
+	}
+	if !slices.Contains(visibilities, secret.Visibility) {
+		return fmt.Errorf("unsupported visibility %q, must be one of %v", secret.Visibility, visibilities)
+	}
+	if secret.Visibility != VisibilitySelected && len(secret.Repositories) != 0 {
+		return fmt.Errorf("repositories are only supported with %s visibility", VisibilitySelected)
+	}
+	return nil
+}
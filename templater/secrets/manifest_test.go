@@ -0,0 +1,98 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package secrets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+)
+
+func TestDeclared(t *testing.T) {
+	for name, tt := range map[string]struct {
+		cfg      config.ConfigFile
+		expected []config.SecretInfo
+		err      string
+	}{
+		"defaults": {
+			cfg: config.ConfigFile{Backports: config.BackportInfo{Bot: config.BotInfo{TokenVariable: "BOT_TOKEN"}}},
+			expected: []config.SecretInfo{
+				{Name: "BOT_TOKEN", Description: "Token used by the backport bot to open pull requests", Scope: ScopeRepository, Required: true, Workflows: []string{"backport.yml"}},
+				{Name: "SLACK_WEBHOOK_URL", Description: "Slack webhook notified about pending pull requests", Scope: ScopeRepository, Workflows: []string{"pending-prs.yml"}},
+			},
+		},
+		"defaults with builtin token": {
+			cfg: config.ConfigFile{},
+			expected: []config.SecretInfo{
+				{Name: "SLACK_WEBHOOK_URL", Description: "Slack webhook notified about pending pull requests", Scope: ScopeRepository, Workflows: []string{"pending-prs.yml"}},
+			},
+		},
+		"declared": {
+			cfg: config.ConfigFile{Secrets: []config.SecretInfo{
+				{Name: "DEPLOY_KEY", Scope: ScopeEnvironment, Environment: "production", Required: true, Workflows: []string{"deploy.yaml"}},
+				{Name: "NPM_TOKEN", Scope: ScopeOrganization},
+				{Name: "DEPLOY_KEY", Scope: ScopeEnvironment, Environment: "staging"},
+				{Name: "NPM_TOKEN", Dependabot: true},
+			}},
+			expected: []config.SecretInfo{
+				{Name: "DEPLOY_KEY", Scope: ScopeEnvironment, Environment: "production", Required: true, Workflows: []string{"deploy.yaml"}},
+				{Name: "NPM_TOKEN", Scope: ScopeOrganization, Visibility: VisibilitySelected},
+				{Name: "DEPLOY_KEY", Scope: ScopeEnvironment, Environment: "staging"},
+				{Name: "NPM_TOKEN", Scope: ScopeRepository, Dependabot: true},
+			},
+		},
+		"duplicate": {
+			cfg: config.ConfigFile{Secrets: []config.SecretInfo{{Name: "TOKEN"}, {Name: "token", Scope: ScopeRepository}}},
+			err: `secret "token" is declared more than once`,
+		},
+		"invalid name": {
+			cfg: config.ConfigFile{Secrets: []config.SecretInfo{{Name: "1TOKEN"}}},
+			err: `invalid secret "1TOKEN": name must only contain alphanumeric characters or underscores and not start with a number`,
+		},
+		"reserved prefix": {
+			cfg: config.ConfigFile{Secrets: []config.SecretInfo{{Name: "GITHUB_APP_KEY"}}},
+			err: `invalid secret "GITHUB_APP_KEY": name must not start with GITHUB_`,
+		},
+		"unsupported scope": {
+			cfg: config.ConfigFile{Secrets: []config.SecretInfo{{Name: "TOKEN", Scope: "enterprise"}}},
+			err: `invalid secret "TOKEN": unsupported scope "enterprise", must be one of [repo environment org]`,
+		},
+		"missing environment": {
+			cfg: config.ConfigFile{Secrets: []config.SecretInfo{{Name: "TOKEN", Scope: ScopeEnvironment}}},
+			err: `invalid secret "TOKEN": environment is required for environment secrets`,
+		},
+		"visibility outside organization": {
+			cfg: config.ConfigFile{Secrets: []config.SecretInfo{{Name: "TOKEN", Visibility: VisibilityAll}}},
+			err: `invalid secret "TOKEN": visibility and repositories are only supported for org secrets`,
+		},
+		"repositories without selected visibility": {
+			cfg: config.ConfigFile{Secrets: []config.SecretInfo{{Name: "TOKEN", Scope: ScopeOrganization, Visibility: VisibilityPrivate, Repositories: []string{"repo"}}}},
+			err: `invalid secret "TOKEN": repositories are only supported with selected visibility`,
+		},
+		"dependabot environment": {
+			cfg: config.ConfigFile{Secrets: []config.SecretInfo{{Name: "TOKEN", Scope: ScopeEnvironment, Environment: "production", Dependabot: true}}},
+			err: `invalid secret "TOKEN": dependabot secrets can't be scoped to an environment`,
+		},
+		"multiple sources": {
+			cfg: config.ConfigFile{Secrets: []config.SecretInfo{{Name: "TOKEN", Source: config.SecretSourceInfo{Env: "TOKEN", File: "token"}}}},
+			err: `invalid secret "TOKEN": only one of env, file, keyring or command can be set as the source`,
+		},
+		"invalid workflow": {
+			cfg: config.ConfigFile{Secrets: []config.SecretInfo{{Name: "TOKEN", Workflows: []string{".github/workflows/ci.yml"}}}},
+			err: `invalid secret "TOKEN": workflow ".github/workflows/ci.yml" must be the file name of a workflow in .github/workflows`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			secrets, err := Declared(tt.cfg)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, secrets)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+)
+
+// Value is a declared secret along with its resolved value.
+type Value struct {
+	config.SecretInfo
+	Value string
+}
+
+// AskFunc prompts for the value of a secret without a configured source.
+type AskFunc func(secret config.SecretInfo) (string, error)
+
+// Collect resolves the value of every declared secret from its configured
+// source, falling back to ask for secrets without one. Secrets without a
+// value are skipped unless they're required. A nil ask makes secrets
+// without a source behave as if they had no value. Keyring lookups are
+// bounded by keyringTimeout, DefaultKeyringTimeout when it's zero.
+func Collect(ctx context.Context, declared []config.SecretInfo, directory string, keyringTimeout time.Duration, ask AskFunc) ([]Value, error) {
+	secrets := []Value{}
+	for _, info := range declared {
+		value, ok, err := Resolve(ctx, info, directory, keyringTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("resolving %q: %w", info.Name, err)
+		}
+		if !ok && ask != nil {
+			if value, err = ask(info); err != nil {
+				return nil, err
+			}
+		}
+
+		value = strings.TrimSpace(value)
+		if value == "" {
+			if info.Required {
+				return nil, fmt.Errorf("secret %q is required but has no value", info.Name)
+			}
+			continue
+		}
+		secrets = append(secrets, Value{SecretInfo: info, Value: value})
+	}
+	return secrets, nil
+}
+
+// Resolve returns the value of a secret from its configured source and
+// whether it has one. An unset environment variable counts as no source so
+// the value can still be asked for. Relative file paths are resolved
+// against the given directory, as are commands.
+func Resolve(ctx context.Context, info config.SecretInfo, directory string, keyringTimeout time.Duration) (string, bool, error) {
+	source := info.Source
+
+	switch {
+	case source.Env != "":
+		value, ok := os.LookupEnv(source.Env)
+		return value, ok, nil
+	case source.File != "":
+		file := source.File
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(directory, file)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", true, err
+		}
+		return strings.TrimSpace(string(data)), true, nil
+	case source.Keyring != nil:
+		// a missing entry has no value
+		value, err := KeyringGet(source.Keyring.Service, source.Keyring.User, keyringTimeout)
+		if errors.Is(err, ErrKeyringNotFound) {
+			return "", true, nil
+		}
+		return value, true, err
+	case len(source.Command) != 0:
+		var stdout, stderr bytes.Buffer
+		command := exec.CommandContext(ctx, source.Command[0], source.Command[1:]...)
+		command.Dir = directory
+		command.Stdout = &stdout
+		command.Stderr = &stderr
+		if err := command.Run(); err != nil {
+			return "", true, fmt.Errorf("running %q: %w: %s", strings.Join(source.Command, " "), err, strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimSpace(stdout.String()), true, nil
+	}
+	return "", false, nil
+}
+
+func validateSource(source config.SecretSourceInfo) error {
+	sources := 0
+	if source.Env != "" {
+		sources++
+	}
+	if source.File != "" {
+		sources++
+	}
+	if source.Keyring != nil {
+		sources++
+		if source.Keyring.Service == "" || source.Keyring.User == "" {
+			return errors.New("keyring source requires a service and a user")
+		}
+	}
+	if len(source.Command) != 0 {
+		sources++
+	}
+	if sources > 1 {
+		return errors.New("only one of env, file, keyring or command can be set as the source")
+	}
+	return nil
+}
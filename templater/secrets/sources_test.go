@@ -0,0 +1,66 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package secrets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+)
+
+func TestCollect(t *testing.T) {
+	ctx := context.Background()
+	directory := t.TempDir()
+
+	keyring.MockInit()
+	require.NoError(t, keyring.Set("templater", "deploy", "from-keyring"))
+	require.NoError(t, os.WriteFile(filepath.Join(directory, "token"), []byte("from-file\n"), 0600))
+	t.Setenv("TEMPLATER_TEST_SECRET", "from-env")
+
+	declared := []config.SecretInfo{
+		{Name: "ENV", Source: config.SecretSourceInfo{Env: "TEMPLATER_TEST_SECRET"}},
+		{Name: "FILE", Source: config.SecretSourceInfo{File: "token"}},
+		{Name: "KEYRING", Source: config.SecretSourceInfo{Keyring: &config.KeyringSourceInfo{Service: "templater", User: "deploy"}}},
+		{Name: "COMMAND", Source: config.SecretSourceInfo{Command: []string{"echo", "from-command"}}},
+		{Name: "MISSING_KEYRING", Source: config.SecretSourceInfo{Keyring: &config.KeyringSourceInfo{Service: "templater", User: "missing"}}},
+		{Name: "PROMPTED"},
+		{Name: "UNSET_ENV", Source: config.SecretSourceInfo{Env: "TEMPLATER_TEST_UNSET_SECRET"}},
+	}
+
+	asked := []string{}
+	secrets, err := Collect(ctx, declared, directory, 0, func(info config.SecretInfo) (string, error) {
+		asked = append(asked, info.Name)
+		return "from-prompt", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"PROMPTED", "UNSET_ENV"}, asked)
+	require.Equal(t, []Value{
+		{SecretInfo: declared[0], Value: "from-env"},
+		{SecretInfo: declared[1], Value: "from-file"},
+		{SecretInfo: declared[2], Value: "from-keyring"},
+		{SecretInfo: declared[3], Value: "from-command"},
+		{SecretInfo: declared[5], Value: "from-prompt"},
+		{SecretInfo: declared[6], Value: "from-prompt"},
+	}, secrets)
+
+	t.Run("non-interactive", func(t *testing.T) {
+		secrets, err := Collect(ctx, declared, directory, 0, nil)
+		require.NoError(t, err)
+		require.Len(t, secrets, 4)
+
+		_, err = Collect(ctx, []config.SecretInfo{{Name: "TOKEN", Required: true}}, directory, 0, nil)
+		require.EqualError(t, err, `secret "TOKEN" is required but has no value`)
+	})
+
+	t.Run("failing command", func(t *testing.T) {
+		_, err := Collect(ctx, []config.SecretInfo{{Name: "TOKEN", Source: config.SecretSourceInfo{Command: []string{"false"}}}}, directory, 0, nil)
+		require.ErrorContains(t, err, `resolving "TOKEN": running "false"`)
+	})
+}
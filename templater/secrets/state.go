@@ -0,0 +1,191 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package secrets
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+)
+
+// Status is the outcome of syncing a single secret.
+type Status string
+
+const (
+	StatusUpdated   Status = "updated"
+	StatusUnchanged Status = "unchanged"
+	StatusFailed    Status = "failed"
+)
+
+// Result is the outcome of syncing a declared secret.
+type Result struct {
+	config.SecretInfo
+	Status Status
+	Err    error
+}
+
+// WriteResults renders the results as a table, one row per secret.
+func WriteResults(w io.Writer, results []Result) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(table, "SECRET\tTARGET\tSTATUS\tDETAILS"); err != nil {
+		return err
+	}
+	for _, result := range results {
+		details := "value set"
+		switch result.Status {
+		case StatusUnchanged:
+			details = "skipped, value was already synced"
+		case StatusFailed:
+			details = result.Err.Error()
+		}
+		if _, err := fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", result.Name, Target(result.SecretInfo), result.Status, details); err != nil {
+			return err
+		}
+	}
+	return table.Flush()
+}
+
+// State records a digest of the values last synced along with the time
+// GitHub reported the secret as updated, so values that haven't changed
+// since can be skipped. Secrets updated outside of templater are synced
+// again since their update time no longer matches. Digests are HMACs keyed
+// by a random per-machine key kept next to the state, so they can't be
+// checked against guessed values without it.
+type State struct {
+	mutex sync.Mutex
+	path  string
+	key   []byte
+
+	Secrets map[string]StateEntry `json:"secrets"`
+}
+
+// StateEntry is the recorded state of a single secret.
+type StateEntry struct {
+	Digest    string    `json:"digest"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DefaultStatePath returns where the sync state is kept, in the user's
+// cache directory since it only avoids redundant writes.
+func DefaultStatePath() (string, error) {
+	directory, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("getting cache directory: %w", err)
+	}
+	return filepath.Join(directory, "templater", "secrets.json"), nil
+}
+
+// LoadState reads the sync state, a missing file is an empty state. The
+// digest key is generated the first time the state is loaded.
+func LoadState(path string) (*State, error) {
+	key, err := loadStateKey(filepath.Join(filepath.Dir(path), "secrets.key"))
+	if err != nil {
+		return nil, fmt.Errorf("loading secrets state key: %w", err)
+	}
+	state := &State{path: path, key: key, Secrets: map[string]StateEntry{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("parsing secrets state %q: %w", path, err)
+	}
+	if state.Secrets == nil {
+		state.Secrets = map[string]StateEntry{}
+	}
+	return state, nil
+}
+
+// StateKey identifies a declared secret of a repository in the state.
+func StateKey(organization, repo string, secret config.SecretInfo) string {
+	parts := []string{organization, repo, secret.Scope}
+	if secret.Scope == ScopeEnvironment {
+		parts = append(parts, secret.Environment)
+	}
+	if secret.Dependabot {
+		parts = append(parts, "dependabot")
+	}
+	return strings.Join(append(parts, secret.Name), "/")
+}
+
+// Unchanged returns whether the value was the last one synced for the key
+// and GitHub still reports the update time recorded with it.
+func (s *State) Unchanged(key, value string, updatedAt time.Time) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, ok := s.Secrets[key]
+	return ok && !updatedAt.IsZero() && entry.UpdatedAt.Equal(updatedAt) && hmac.Equal([]byte(entry.Digest), []byte(s.digest(key, value)))
+}
+
+// Record stores the value synced for the key and the update time GitHub
+// reported afterwards.
+func (s *State) Record(key, value string, updatedAt time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.Secrets[key] = StateEntry{Digest: s.digest(key, value), UpdatedAt: updatedAt}
+}
+
+// Save writes the state back to where it was loaded from.
+func (s *State) Save() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0600)
+}
+
+func (s *State) digest(key, value string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(key + "\x00" + value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// loadStateKey reads the digest key, generating it when it doesn't exist.
+// Digests recorded under a previous key simply no longer match.
+func loadStateKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return hex.DecodeString(strings.TrimSpace(string(data)))
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,65 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrewstucki/actions-testing/templater/config"
+)
+
+func TestState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "templater", "secrets.json")
+	updatedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	state, err := LoadState(path)
+	require.NoError(t, err)
+
+	key := StateKey("org", "repo", config.SecretInfo{Name: "DEPLOY_KEY", Scope: ScopeEnvironment, Environment: "production"})
+	require.Equal(t, "org/repo/environment/production/DEPLOY_KEY", key)
+	require.False(t, state.Unchanged(key, "value", updatedAt))
+
+	state.Record(key, "value", updatedAt)
+	require.NoError(t, state.Save())
+
+	state, err = LoadState(path)
+	require.NoError(t, err)
+	require.True(t, state.Unchanged(key, "value", updatedAt))
+	require.False(t, state.Unchanged(key, "other", updatedAt))
+	require.False(t, state.Unchanged(key, "value", updatedAt.Add(time.Second)))
+	require.NotContains(t, state.Secrets[key].Digest, "value")
+
+	info, err := os.Stat(filepath.Join(filepath.Dir(path), "secrets.key"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	t.Run("new key", func(t *testing.T) {
+		require.NoError(t, os.Remove(filepath.Join(filepath.Dir(path), "secrets.key")))
+
+		state, err := LoadState(path)
+		require.NoError(t, err)
+		require.False(t, state.Unchanged(key, "value", updatedAt))
+	})
+}
+
+func TestWriteResults(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, WriteResults(&buffer, []Result{
+		{SecretInfo: config.SecretInfo{Name: "BOT_TOKEN", Scope: ScopeRepository}, Status: StatusUpdated},
+		{SecretInfo: config.SecretInfo{Name: "SLACK_WEBHOOK_URL", Scope: ScopeRepository}, Status: StatusUnchanged},
+		{SecretInfo: config.SecretInfo{Name: "DEPLOY_KEY", Scope: ScopeEnvironment, Environment: "production"}, Status: StatusFailed, Err: errors.New("403 Forbidden")},
+	}))
+	require.Equal(t, `SECRET             TARGET                    STATUS     DETAILS
+BOT_TOKEN          repository                updated    value set
+SLACK_WEBHOOK_URL  repository                unchanged  skipped, value was already synced
+DEPLOY_KEY         environment "production"  failed     403 Forbidden
+`, buffer.String())
+}
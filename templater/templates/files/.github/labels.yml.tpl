@@ -3,7 +3,10 @@ labels:
   "{{ $version }}":
     color: "ededed"
   {{- end }}
-  "no-changelog":
-    color: "8f1402"
-  "stale":
-    color: "8f1402"
+  {{- range $label := .Labels }}
+  "{{ $label.Name }}":
+    color: "{{ $label.Color }}"
+    {{- if $label.Description }}
+    description: {{ printf "%q" $label.Description }}
+    {{- end }}
+  {{- end }}
@@ -0,0 +1,95 @@
+name: CI
+
+on:
+  push:
+    branches:
+      - main
+  pull_request:
+
+jobs:
+{{- range $i, $language := .ProjectLanguages }}
+{{- if $i }}
+{{ end }}
+  {{ if $.IsMultiLanguage }}test-{{ $language }}{{ else }}test{{ end }}:
+    name: Build and test
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        directory: {{ $.JSONProjectDirectories $language }}
+    steps:
+      - uses: actions/checkout@v4
+{{- if eq $language "go" }}
+
+      - uses: actions/setup-go@v5
+        with:
+          go-version-file: {{ "${{ matrix.directory }}" }}/go.mod
+
+      - name: Build
+        working-directory: {{ "${{ matrix.directory }}" }}
+        run: go build ./...
+
+      - name: Vet
+        working-directory: {{ "${{ matrix.directory }}" }}
+        run: go vet ./...
+
+      - name: Test
+        working-directory: {{ "${{ matrix.directory }}" }}
+        run: go test ./...
+{{- else if eq $language "typescript" }}
+
+      - uses: actions/setup-node@v4
+        with:
+          node-version: 22
+
+      - name: Install
+        working-directory: {{ "${{ matrix.directory }}" }}
+        run: npm install
+
+      - name: Build
+        working-directory: {{ "${{ matrix.directory }}" }}
+        run: npm run build
+
+      - name: Test
+        working-directory: {{ "${{ matrix.directory }}" }}
+        run: npm test
+{{- else if eq $language "python" }}
+
+      - uses: actions/setup-python@v5
+        with:
+          python-version: "3.12"
+
+      - name: Install
+        working-directory: {{ "${{ matrix.directory }}" }}
+        run: pip install .
+
+      - name: Test
+        working-directory: {{ "${{ matrix.directory }}" }}
+        run: python -m unittest discover
+{{- else if eq $language "rust" }}
+
+      - uses: dtolnay/rust-toolchain@stable
+
+      - name: Build
+        working-directory: {{ "${{ matrix.directory }}" }}
+        run: cargo build
+
+      - name: Test
+        working-directory: {{ "${{ matrix.directory }}" }}
+        run: cargo test
+{{- end }}
+{{- end }}
+{{- if .LicenseManagement }}
+
+  license-headers:
+    name: Check license headers
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+
+      - uses: actions/setup-go@v5
+        with:
+          go-version: stable
+
+      - name: Check license headers
+        run: go run {{ .Templater }} license headers --check
+{{- end }}
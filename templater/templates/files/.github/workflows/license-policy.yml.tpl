@@ -0,0 +1,28 @@
+{{- if and .LicenseManagement (.HasLanguage "go") -}}
+name: License Policy
+
+on:
+  pull_request:
+    paths:
+      - "**/go.mod"
+      - "**/go.sum"
+      - .template.yaml
+  workflow_dispatch:
+
+jobs:
+  check:
+    name: Check dependency licenses
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        directory: {{ .JSONProjectDirectories "go" }}
+    steps:
+      - uses: actions/checkout@v4
+
+      - uses: actions/setup-go@v5
+        with:
+          go-version-file: {{ "${{ matrix.directory }}" }}/go.mod
+
+      - name: Check dependency licenses
+        run: go run {{ .Templater }} license check --dir {{ "${{ matrix.directory }}" }}
+{{- end -}}
@@ -0,0 +1,27 @@
+# Executables
+*.exe
+# Output of the go coverage tool, specifically when used with LiteIDE
+*.out
+*.app
+/*build*
+*.idea
+.DS_Store
+# vim
+.*.sw?
+# direnv files
+.direnv/
+# task cache directory
+.task/{{- if .HasLanguage "typescript" }}
+# node
+node_modules/
+dist/
+{{- end }}{{- if .HasLanguage "python" }}
+# python
+__pycache__/
+*.py[cod]
+.venv/
+*.egg-info/
+{{- end }}{{- if .HasLanguage "rust" }}
+# cargo
+target/
+{{- end }}
\ No newline at end of file
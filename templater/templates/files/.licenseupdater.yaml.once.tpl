@@ -2,8 +2,13 @@
 organization: {{ .Copyright }}
 top_level_license: {{ .License }}
 matches:
-  - type: go
+{{- range .Projects }}
+  - type: {{ .Language }}
     short: true
-    extension: .go
-    license: {{ .License }}
+    extension: {{ .Language.Extension }}
+    {{- if ne .Directory "." }}
+    directory: {{ .Directory }}
+    {{- end }}
+    license: {{ $.License }}
+{{- end }}
 {{- end -}}
\ No newline at end of file
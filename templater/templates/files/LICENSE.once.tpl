@@ -0,0 +1 @@
+{{ .LicenseText }}
\ No newline at end of file
@@ -14,23 +14,72 @@ tasks:
       - task: write-license-headers
 
   generate-third-party-licenses:
-    dir: {{ .Source }}
+    cmds:
+    {{- range .Projects }}
+      - task: generate-third-party-licenses-{{ .Name }}
+    {{- end }}
+  {{- range .Projects }}
+
+  generate-third-party-licenses-{{ .Name }}:
+    dir: {{ .Directory }}
     method: checksum
     generates:
       - third_party_licenses.md
     sources:
+    {{- if eq .Language "go" }}
       - ./go.mod
       - ./go.sum
-    cmds: 
+    cmds:
       - |
-        go-licenses report ./... --template ./support/files/third_party_licenses.md.tpl \
-        --ignore {{ .GithubURL }} > ./third_party_licenses.md
+        go run {{ $.Templater }} license report --config {{ .RootPath }}/.template.yaml \
+        --ignore {{ $.GithubURL }} --template ./support/files/third_party_licenses.md.tpl \
+        --output ./third_party_licenses.md
+    {{- else if eq .Language "typescript" }}
+      - ./package.json
+      - ./package-lock.json
+    cmds:
+      - npx --yes license-checker --production --excludePrivatePackages --markdown --out ./third_party_licenses.md
+    {{- else if eq .Language "python" }}
+      - ./pyproject.toml
+    cmds:
+      - pip-licenses --from=mixed --format=markdown --with-urls --output-file=./third_party_licenses.md
+    {{- else if eq .Language "rust" }}
+      - ./Cargo.toml
+      - ./Cargo.lock
+    cmds:
+      - |
+        cargo about generate --config ./support/files/about.toml \
+        ./support/files/third_party_licenses.hbs > ./third_party_licenses.md
+    {{- end }}
+  {{- end }}
 
   write-license-headers:
     cmds:
       - licenseupdater
   {{- end }}
 
+  {{- range .ProjectsWithLanguage "go" }}
+
+  test-{{ .Name }}:
+    dir: {{ .Directory }}
+    cmds:
+      - go test ./...
+  {{- if .Scaffold.IsExecutable }}
+
+  build-{{ .Name }}:
+    dir: {{ .Directory }}
+    cmds:
+      - go build -o {{ "{{.ROOT_DIR}}" }}/.build/{{ .Name }} .
+  {{- end }}
+  {{- if eq .Scaffold "service" }}
+
+  run-{{ .Name }}:
+    dir: {{ .Directory }}
+    cmds:
+      - go run .
+  {{- end }}
+  {{- end }}
+
   pending-prs:
     desc: "Get all pending PRs for watched branches"
     silent: true
@@ -46,13 +46,33 @@
               pkgs.backport
               {{- end }}
               pkgs.changie # Changelog manager
+              {{- if .HasLanguage "go" }}
               pkgs.cobra-cli
+              {{- end }}
               pkgs.gawk # GNU awk, used by some build scripts.
               pkgs.gh
               pkgs.gnused # Stream Editor, used by some build scripts.
+              {{- if .HasLanguage "go" }}
+              pkgs.go
+              {{- end }}
+              {{- if .HasLanguage "typescript" }}
+              pkgs.nodejs
+              {{- end }}
+              {{- if .HasLanguage "python" }}
+              pkgs.python3
+              {{- end }}
+              {{- if .HasLanguage "rust" }}
+              pkgs.cargo
+              pkgs.rustc
+              {{- end }}
               pkgs.go-task
               {{- if .LicenseManagement }}
-              pkgs.go-licenses
+              {{- if .HasLanguage "python" }}
+              pkgs.python3Packages.pip-licenses
+              {{- end }}
+              {{- if .HasLanguage "rust" }}
+              pkgs.cargo-about
+              {{- end }}
               pkgs.licenseupdater
               {{- end }}
               pkgs.yq-go
@@ -1 +0,0 @@
-module {{ .GithubURL }}
@@ -0,0 +1,8 @@
+{{- $projects := .ProjectsWithLanguage "go" }}
+{{- if gt (len $projects) 1 -}}
+use (
+{{- range $projects }}
+	{{ if eq .Directory "." }}.{{ else }}./{{ .Directory }}{{ end }}
+{{- end }}
+)
+{{ end -}}
@@ -12,19 +12,35 @@ import (
 	"io/fs"
 	"os"
 	"path"
+	"slices"
 	"strings"
 	"text/template"
+	"time"
+
+	"github.com/andrewstucki/actions-testing/templater/licenses"
 )
 
 var (
 	//go:embed all:files
 	templateFiles embed.FS
+	//go:embed all:project
+	projectFiles embed.FS
 
 	defaultBackportLabel             = "backport"
 	defaultGithubBackportBot         = "github-actions[bot]"
 	defaultGithubBackportBotTokenVar = "GITHUB_TOKEN"
 	defaultRenderer                  = &Renderer{}
+	licenseFile                      = "LICENSE"
+	now                              = time.Now
+	version                          = buildVersion
 	Update                           = &Renderer{IsUpdate: true}
+
+	// defaultLabels are the labels the templated workflows rely on, a
+	// configured label replaces the default label of the same name.
+	defaultLabels = []LabelInfo{
+		{Name: "no-changelog", Color: "8f1402"},
+		{Name: "stale", Color: "8f1402"},
+	}
 )
 
 // TemplateInfo is the info to render into our templates.
@@ -39,17 +55,80 @@ type TemplateInfo struct {
 	LabelMapper          map[string]string
 	Source               string
 	License              string
+	Year                 int
+	Language             Language
 	BackportBot          string
 	BackportBotTokenVar  string
 	LicenseManagement    bool
 	Backports            bool
 	AutoApproveBackports bool
+	Labels               []LabelInfo
+	TemplaterVersion     string
 }
 
 // ProjectInfo is the info of a project with a mapping to its Changelog
+// and the directory and module path it lives at. Language defaults to the
+// language of the repository.
 type ProjectInfo struct {
 	Name      string
 	Changelog string
+	Directory string
+	Module    string
+	Language  Language
+	Scaffold  Scaffold
+}
+
+// PackageName returns the Go package name derived from the project's module
+// path, ignoring any major version suffix
+func (p ProjectInfo) PackageName() string {
+	module := p.Module
+	if parent, version := path.Split(module); parent != "" && isMajorVersion(version) {
+		module = path.Clean(parent)
+	}
+
+	var name strings.Builder
+	for _, r := range strings.ToLower(path.Base(module)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9' && name.Len() > 0) {
+			name.WriteRune(r)
+		}
+	}
+	if name.Len() == 0 {
+		return "lib"
+	}
+	return name.String()
+}
+
+// RootPath returns the relative path from the project's directory back to
+// the root of the repository
+func (p ProjectInfo) RootPath() string {
+	if p.Directory == "." {
+		return "."
+	}
+	return strings.TrimSuffix(strings.Repeat("../", strings.Count(p.Directory, "/")+1), "/")
+}
+
+// isMajorVersion returns whether the path element is a module major
+// version suffix such as v2
+func isMajorVersion(element string) bool {
+	digits, ok := strings.CutPrefix(element, "v")
+	if !ok || digits == "" || digits[0] == '0' {
+		return false
+	}
+	return strings.Trim(digits, "0123456789") == ""
+}
+
+// LabelInfo is a label rendered into .github/labels.yml.
+type LabelInfo struct {
+	Name        string
+	Color       string
+	Description string
+}
+
+// ProjectTemplateInfo is the info to render into the templates
+// rendered once for every project.
+type ProjectTemplateInfo struct {
+	TemplateInfo
+	Project ProjectInfo
 }
 
 // NormalizeAndValidate returns an error if the TemplateInfo is invalid
@@ -58,9 +137,20 @@ func (t *TemplateInfo) NormalizeAndValidate() error {
 	if t.Source == "" {
 		t.Source = "."
 	}
+	t.Source = path.Clean(t.Source)
 	if t.Copyright == "" {
 		t.Copyright = t.Organization
 	}
+	if t.Language == "" {
+		t.Language = LanguageGo
+	}
+	if t.Year == 0 {
+		t.Year = now().Year()
+	}
+	if t.TemplaterVersion == "" {
+		t.TemplaterVersion = version()
+	}
+	t.Labels = withDefaultLabels(t.Labels)
 	if t.BackportBot == "" {
 		t.BackportBot = defaultGithubBackportBot
 	}
@@ -77,10 +167,33 @@ func (t *TemplateInfo) NormalizeAndValidate() error {
 	}
 	if len(t.Projects) == 0 {
 		t.Projects = append(t.Projects, ProjectInfo{
-			Name:      t.Repository,
-			Changelog: "CHANGELOG.md",
+			Name: t.Repository,
 		})
 	}
+	for i := range t.Projects {
+		project := &t.Projects[i]
+		if project.Directory == "" {
+			project.Directory = t.Source
+		}
+		project.Directory = path.Clean(project.Directory)
+		if project.Changelog == "" {
+			// a single project keeps its changelog at the root of the
+			// repository, even when its module lives under Source
+			project.Changelog = "CHANGELOG.md"
+			if len(t.Projects) > 1 {
+				project.Changelog = path.Join(project.Directory, "CHANGELOG.md")
+			}
+		}
+		if project.Module == "" {
+			project.Module = t.moduleFor(project.Directory)
+		}
+		if project.Language == "" {
+			project.Language = t.Language
+		}
+		if project.Scaffold == "" {
+			project.Scaffold = ScaffoldMain
+		}
+	}
 
 	// Validation
 	var errs []error
@@ -93,19 +206,126 @@ func (t *TemplateInfo) NormalizeAndValidate() error {
 	if t.License == "" {
 		errs = append(errs, errors.New("License must be specified"))
 	}
+	if language, err := ParseLanguage(string(t.Language)); err != nil {
+		errs = append(errs, err)
+	} else {
+		t.Language = language
+	}
+	names := map[string]struct{}{}
+	directories := map[string]struct{}{}
+	for i, project := range t.Projects {
+		if project.Name == "" {
+			errs = append(errs, errors.New("Project name must be specified"))
+		}
+		if _, ok := names[project.Name]; ok {
+			errs = append(errs, fmt.Errorf("Project name %q must be unique", project.Name))
+		}
+		if _, ok := directories[project.Directory]; ok {
+			errs = append(errs, fmt.Errorf("Project directory %q must be unique", project.Directory))
+		}
+		if path.IsAbs(project.Directory) || project.Directory == ".." || strings.HasPrefix(project.Directory, "../") {
+			errs = append(errs, fmt.Errorf("Project directory %q must be relative to the repository", project.Directory))
+		}
+		if project.Language != t.Language {
+			if language, err := ParseLanguage(string(project.Language)); err != nil {
+				errs = append(errs, err)
+			} else {
+				t.Projects[i].Language = language
+			}
+		} else {
+			t.Projects[i].Language = t.Language
+		}
+		if scaffold, err := ParseScaffold(string(project.Scaffold)); err != nil {
+			errs = append(errs, err)
+		} else if scaffold != ScaffoldMain && t.Projects[i].Language != LanguageGo {
+			errs = append(errs, fmt.Errorf("Project scaffold %q is only supported for go projects", scaffold))
+		} else {
+			t.Projects[i].Scaffold = scaffold
+		}
+		names[project.Name] = struct{}{}
+		directories[project.Directory] = struct{}{}
+	}
 
 	return errors.Join(errs...)
 }
 
 // GithubURL returns the github path to this Go project
 func (t TemplateInfo) GithubURL() string {
+	return t.moduleFor(t.Source)
+}
+
+// Templater returns the templater module pinned to the version generated
+// tasks and workflows should run
+func (t TemplateInfo) Templater() string {
+	return templaterModule + "@" + t.TemplaterVersion
+}
+
+// LicenseText returns the full text of the project's license
+func (t TemplateInfo) LicenseText() (string, error) {
+	return licenses.Render(t.License, t.Copyright, t.Year)
+}
+
+// moduleFor returns the Go module path for a project in the given directory
+func (t TemplateInfo) moduleFor(directory string) string {
 	github := "github.com/"
-	if t.Source != "." {
-		return github + t.Organization + "/" + t.Repository + "/" + t.Source
+	if directory != "." {
+		return github + t.Organization + "/" + t.Repository + "/" + directory
 	}
 	return github + t.Organization + "/" + t.Repository
 }
 
+// IsMultiModule returns whether the repository contains more than one project
+func (t TemplateInfo) IsMultiModule() bool {
+	return len(t.Projects) > 1
+}
+
+// HasLanguage returns whether any project is written in the given language
+func (t TemplateInfo) HasLanguage(language Language) bool {
+	return len(t.ProjectsWithLanguage(language)) != 0
+}
+
+// ProjectsWithLanguage returns the projects written in the given language
+func (t TemplateInfo) ProjectsWithLanguage(language Language) []ProjectInfo {
+	projects := []ProjectInfo{}
+	for _, project := range t.Projects {
+		if project.Language == language {
+			projects = append(projects, project)
+		}
+	}
+	return projects
+}
+
+// ProjectLanguages returns the languages of all projects in the order
+// they're first used
+func (t TemplateInfo) ProjectLanguages() []Language {
+	languages := []Language{}
+	for _, project := range t.Projects {
+		if !slices.Contains(languages, project.Language) {
+			languages = append(languages, project.Language)
+		}
+	}
+	return languages
+}
+
+// IsMultiLanguage returns whether the projects are written in more than one language
+func (t TemplateInfo) IsMultiLanguage() bool {
+	return len(t.ProjectLanguages()) > 1
+}
+
+// JSONProjectDirectories returns the directories of the projects written
+// in the given language as a JSON array
+func (t TemplateInfo) JSONProjectDirectories(language Language) string {
+	directories := []string{}
+	for _, project := range t.ProjectsWithLanguage(language) {
+		directories = append(directories, project.Directory)
+	}
+	data, err := json.Marshal(directories)
+	if err != nil {
+		panic(fmt.Errorf("creating project directories: %w", err))
+	}
+	return string(data)
+}
+
 func (t TemplateInfo) JSONBranches() string {
 	branches, err := json.Marshal(t.BackportBranches)
 	if err != nil {
@@ -163,26 +383,29 @@ func RenderTo(directory string, info TemplateInfo) error {
 }
 
 // RenderTo renders all of our templates using the given info
-// into the given directory.
+// into the given directory. Templates that are only rendered once
+// aren't rendered at all when their file already exists.
 func (r *Renderer) RenderTo(directory string, info TemplateInfo) error {
-	files, err := Render(info)
+	files, err := r.render(info, func(name string) bool {
+		if r.IgnoreOnce {
+			return false
+		}
+		_, err := os.Stat(r.fileName(directory, name))
+		return !os.IsNotExist(err)
+	})
 	if err != nil {
 		return err
 	}
 
 	var errs []error
 	for _, file := range files {
-		fileName := path.Join(directory, file.Name)
+		fileName := r.fileName(directory, file.Name)
 		parent := path.Dir(fileName)
 		if err := os.MkdirAll(parent, 0755); err != nil {
 			errs = append(errs, fmt.Errorf("creating parent directory: %w", err))
 			continue
 		}
 
-		if r.Suffix != "" {
-			fileName += "." + r.Suffix
-		}
-
 		_, err := os.Stat(fileName)
 		if os.IsNotExist(err) || !file.Once || r.IgnoreOnce {
 			permissions := os.FileMode(0644)
@@ -206,14 +429,64 @@ func Render(info TemplateInfo) ([]File, error) {
 // Render renders all of our templates using the given info
 // and returns all of the rendered templates.
 func (r *Renderer) Render(info TemplateInfo) ([]File, error) {
+	return r.render(info, nil)
+}
+
+// fileName returns where a rendered file is written in the given directory.
+func (r *Renderer) fileName(directory, name string) string {
+	fileName := path.Join(directory, name)
+	if r.Suffix != "" {
+		fileName += "." + r.Suffix
+	}
+	return fileName
+}
+
+// render renders the templates, skipping templates rendered only once
+// when exists reports their file is already there. The license is only
+// validated when LICENSE is rendered, so repositories whose license isn't
+// embedded can still be updated.
+func (r *Renderer) render(info TemplateInfo, exists func(name string) bool) ([]File, error) {
 	if err := info.NormalizeAndValidate(); err != nil {
 		return nil, err
 	}
+	if exists == nil || !exists(licenseFile) {
+		if err := licenses.Validate(info.License); err != nil {
+			return nil, err
+		}
+	}
 
+	renderedFiles, err := renderFS(templateFiles, "files", "", info, exists)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, project := range info.Projects {
+		files, err := renderFS(projectFiles, "project", project.Directory, ProjectTemplateInfo{
+			TemplateInfo: info,
+			Project:      project,
+		}, exists)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rendering project %q: %w", project.Name, err))
+			continue
+		}
+		renderedFiles = append(renderedFiles, files...)
+	}
+
+	if len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return renderedFiles, nil
+}
+
+// renderFS renders all of the templates found under root in the given
+// filesystem, placing the rendered files relative to directory.
+func renderFS(fsys embed.FS, root, directory string, info any, exists func(name string) bool) ([]File, error) {
 	var renderedFiles []File
 	var errs []error
 
-	if err := fs.WalkDir(templateFiles, ".", func(fullPath string, d fs.DirEntry, err error) error {
+	if err := fs.WalkDir(fsys, root, func(fullPath string, d fs.DirEntry, err error) error {
 		if err != nil {
 			errs = append(errs, err)
 			return nil
@@ -223,7 +496,7 @@ func (r *Renderer) Render(info TemplateInfo) ([]File, error) {
 			var buffer bytes.Buffer
 			once := false
 
-			name := strings.TrimPrefix(fullPath, "files/")
+			name := strings.TrimPrefix(fullPath, root+"/")
 			isTemplate := strings.HasSuffix(name, ".tpl")
 			if isTemplate {
 				name = strings.TrimSuffix(name, ".tpl")
@@ -244,7 +517,11 @@ func (r *Renderer) Render(info TemplateInfo) ([]File, error) {
 			isExecute := strings.HasSuffix(name, ".execute")
 			name = strings.TrimSuffix(name, ".execute")
 
-			data, err := templateFiles.ReadFile(fullPath)
+			if once && exists != nil && exists(path.Join(directory, name)) {
+				return nil
+			}
+
+			data, err := fsys.ReadFile(fullPath)
 			if err != nil {
 				errs = append(errs, err)
 				return nil
@@ -280,7 +557,7 @@ func (r *Renderer) Render(info TemplateInfo) ([]File, error) {
 
 			renderedFiles = append(renderedFiles, File{
 				Data:       buffer.Bytes(),
-				Name:       name,
+				Name:       path.Join(directory, name),
 				Once:       once,
 				Executable: isExecute,
 			})
@@ -297,3 +574,18 @@ func (r *Renderer) Render(info TemplateInfo) ([]File, error) {
 
 	return renderedFiles, nil
 }
+
+// withDefaultLabels returns the default labels followed by the given ones,
+// leaving out defaults that are overridden.
+func withDefaultLabels(labels []LabelInfo) []LabelInfo {
+	merged := []LabelInfo{}
+	for _, label := range defaultLabels {
+		overridden := slices.ContainsFunc(labels, func(override LabelInfo) bool {
+			return strings.EqualFold(override.Name, label.Name)
+		})
+		if !overridden {
+			merged = append(merged, label)
+		}
+	}
+	return append(merged, labels...)
+}
@@ -4,11 +4,13 @@
 package templates
 
 import (
+	"errors"
 	"io/fs"
 	"os"
 	"path"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -28,6 +30,12 @@ func init() {
 	if os.Getenv("RENDER_GOLDEN_FILES") == "true" {
 		renderGolden = true
 	}
+	now = func() time.Time {
+		return time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	}
+	version = func() string {
+		return "v1.0.0"
+	}
 }
 
 func TestRenderTo(t *testing.T) {
@@ -57,6 +65,9 @@ func TestRenderTo(t *testing.T) {
 				License:              "MIT",
 				Backports:            true,
 				AutoApproveBackports: true,
+				Labels: []LabelInfo{
+					{Name: "kind/bug", Color: "d73a4a", Description: "Something isn't working"},
+				},
 			},
 		},
 		"source": {
@@ -69,6 +80,130 @@ func TestRenderTo(t *testing.T) {
 				License:           "MIT",
 			},
 		},
+		"typescript": {
+			info: TemplateInfo{
+				LicenseManagement: true,
+				Organization:      "org",
+				Repository:        "repo",
+				License:           "MIT",
+				Language:          LanguageTypeScript,
+			},
+		},
+		"python": {
+			info: TemplateInfo{
+				LicenseManagement: true,
+				Organization:      "org",
+				Repository:        "repo",
+				License:           "MIT",
+				Language:          LanguagePython,
+			},
+		},
+		"rust": {
+			info: TemplateInfo{
+				LicenseManagement: true,
+				Organization:      "org",
+				Repository:        "repo",
+				License:           "MIT",
+				Language:          LanguageRust,
+			},
+		},
+		"multi-module": {
+			info: TemplateInfo{
+				LicenseManagement: true,
+				Organization:      "org",
+				Repository:        "repo",
+				License:           "MIT",
+				Projects: []ProjectInfo{
+					{Name: "operator", Directory: "operator"},
+					{Name: "charts", Directory: "charts", Module: "example.com/charts"},
+				},
+			},
+		},
+		"multi-language": {
+			info: TemplateInfo{
+				LicenseManagement: true,
+				Organization:      "org",
+				Repository:        "repo",
+				License:           "MIT",
+				Projects: []ProjectInfo{
+					{Name: "operator", Directory: "operator"},
+					{Name: "ui", Directory: "ui", Language: LanguageTypeScript},
+				},
+			},
+		},
+		"unsupported-project-language": {
+			info: TemplateInfo{
+				Organization: "org",
+				Repository:   "repo",
+				License:      "MIT",
+				Projects: []ProjectInfo{
+					{Name: "legacy", Language: "cobol"},
+				},
+			},
+			err: errors.New(`unsupported language "cobol", must be one of go, typescript, python, rust`),
+		},
+		"scaffolds": {
+			info: TemplateInfo{
+				Organization: "org",
+				Repository:   "repo",
+				License:      "MIT",
+				Projects: []ProjectInfo{
+					{Name: "cli", Directory: "cli", Scaffold: ScaffoldCLI},
+					{Name: "client-lib", Directory: "client-lib", Scaffold: ScaffoldLibrary},
+					{Name: "server", Directory: "server", Scaffold: ScaffoldService},
+				},
+			},
+		},
+		"unsupported-scaffold": {
+			info: TemplateInfo{
+				Organization: "org",
+				Repository:   "repo",
+				License:      "MIT",
+				Language:     LanguageRust,
+				Projects: []ProjectInfo{
+					{Name: "cli", Scaffold: ScaffoldCLI},
+				},
+			},
+			err: errors.New(`Project scaffold "cli" is only supported for go projects`),
+		},
+		"duplicate-project-directory": {
+			info: TemplateInfo{
+				Organization: "org",
+				Repository:   "repo",
+				License:      "MIT",
+				Projects: []ProjectInfo{
+					{Name: "one", Directory: "module"},
+					{Name: "two", Directory: "module/"},
+				},
+			},
+			err: errors.New(`Project directory "module" must be unique`),
+		},
+		"apache": {
+			info: TemplateInfo{
+				Organization: "org",
+				Repository:   "repo",
+				Copyright:    "My Organization",
+				License:      "Apache-2.0",
+				Year:         2024,
+			},
+		},
+		"unsupported-license": {
+			info: TemplateInfo{
+				Organization: "org",
+				Repository:   "repo",
+				License:      "WTFPL",
+			},
+			err: errors.New(`unsupported license "WTFPL", must be one of Apache-2.0, BSD-2-Clause, BSD-3-Clause, GPL-3.0-only, GPL-3.0-or-later, ISC, MIT, MPL-2.0, Unlicense`),
+		},
+		"unsupported-language": {
+			info: TemplateInfo{
+				Organization: "org",
+				Repository:   "repo",
+				License:      "MIT",
+				Language:     "cobol",
+			},
+			err: errors.New(`unsupported language "cobol", must be one of go, typescript, python, rust`),
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			fileName := strings.SplitN(t.Name(), "/", 2)[1]
@@ -90,6 +225,81 @@ func TestRenderTo(t *testing.T) {
 	}
 }
 
+func TestUpdateUnsupportedLicense(t *testing.T) {
+	directory := t.TempDir()
+	info := TemplateInfo{
+		Organization: "org",
+		Repository:   "repo",
+		License:      "MIT",
+	}
+	require.NoError(t, RenderTo(directory, info))
+
+	license, err := os.ReadFile(path.Join(directory, "LICENSE"))
+	require.NoError(t, err)
+
+	info.License = "LicenseRef-Proprietary"
+	require.NoError(t, Update.RenderTo(directory, info))
+
+	updated, err := os.ReadFile(path.Join(directory, "LICENSE"))
+	require.NoError(t, err)
+	require.Equal(t, string(license), string(updated))
+
+	require.EqualError(t, Update.RenderTo(t.TempDir(), info), `unsupported license "LicenseRef-Proprietary", must be one of Apache-2.0, BSD-2-Clause, BSD-3-Clause, GPL-3.0-only, GPL-3.0-or-later, ISC, MIT, MPL-2.0, Unlicense`)
+}
+
+func TestPackageName(t *testing.T) {
+	for module, expected := range map[string]string{
+		"github.com/org/repo":         "repo",
+		"github.com/org/client-lib":   "clientlib",
+		"github.com/org/repo/v2":      "repo",
+		"github.com/org/repo/v10":     "repo",
+		"github.com/org/repo/v0":      "v0",
+		"github.com/org/repo/version": "version",
+		"github.com/org/2fa":          "fa",
+		"github.com/org/---":          "lib",
+	} {
+		t.Run(module, func(t *testing.T) {
+			require.Equal(t, expected, ProjectInfo{Module: module}.PackageName())
+		})
+	}
+}
+
+func TestRootPath(t *testing.T) {
+	for directory, expected := range map[string]string{
+		".":         ".",
+		"operator":  "..",
+		"cmd/agent": "../..",
+	} {
+		require.Equal(t, expected, ProjectInfo{Directory: directory}.RootPath())
+	}
+}
+
+func TestProjectDirectories(t *testing.T) {
+	for directory, err := range map[string]string{
+		"operator":     "",
+		"..operator":   "",
+		"a/../b":       "",
+		"..":           `Project directory ".." must be relative to the repository`,
+		"../operator":  `Project directory "../operator" must be relative to the repository`,
+		"a/../../b":    `Project directory "../b" must be relative to the repository`,
+		"/tmp/project": `Project directory "/tmp/project" must be relative to the repository`,
+	} {
+		t.Run(directory, func(t *testing.T) {
+			info := TemplateInfo{
+				Organization: "org",
+				Repository:   "repo",
+				License:      "MIT",
+				Projects:     []ProjectInfo{{Name: "project", Directory: directory}},
+			}
+			if err == "" {
+				require.NoError(t, info.NormalizeAndValidate())
+			} else {
+				require.EqualError(t, info.NormalizeAndValidate(), err)
+			}
+		})
+	}
+}
+
 type testFile struct {
 	name string
 	data string
@@ -0,0 +1,57 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package templates
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Language is the programming language a project is written in. It
+// selects the manifest skeleton, license report tooling, license header
+// matches and CI steps that get rendered.
+type Language string
+
+const (
+	LanguageGo         Language = "go"
+	LanguageTypeScript Language = "typescript"
+	LanguagePython     Language = "python"
+	LanguageRust       Language = "rust"
+)
+
+// Languages are all of the supported project languages.
+var Languages = []Language{LanguageGo, LanguageTypeScript, LanguagePython, LanguageRust}
+
+// ParseLanguage normalizes the given language name and returns an error
+// if it isn't supported.
+func ParseLanguage(name string) (Language, error) {
+	language := Language(strings.ToLower(strings.TrimSpace(name)))
+	if !slices.Contains(Languages, language) {
+		return "", fmt.Errorf("unsupported language %q, must be one of %s", name, joinLanguages())
+	}
+	return language, nil
+}
+
+// Extension returns the file extension used by source files of the language.
+func (l Language) Extension() string {
+	switch l {
+	case LanguageTypeScript:
+		return ".ts"
+	case LanguagePython:
+		return ".py"
+	case LanguageRust:
+		return ".rs"
+	default:
+		return ".go"
+	}
+}
+
+func joinLanguages() string {
+	names := []string{}
+	for _, language := range Languages {
+		names = append(names, string(language))
+	}
+	return strings.Join(names, ", ")
+}
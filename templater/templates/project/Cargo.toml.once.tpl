@@ -0,0 +1,10 @@
+{{- if eq .Project.Language "rust" -}}
+[package]
+name = "{{ .Project.Name }}"
+version = "0.0.0"
+edition = "2021"
+license = "{{ .License }}"
+publish = false
+
+[dependencies]
+{{ end -}}
\ No newline at end of file
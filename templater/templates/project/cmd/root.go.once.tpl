@@ -0,0 +1,27 @@
+{{- if and (eq .Project.Language "go") (eq .Project.Scaffold "cli") -}}
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// rootCmd represents the base command when called without any subcommands
+var rootCmd = &cobra.Command{
+	Use:   "{{ .Project.Name }}",
+	Short: "A brief description of your application",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Println("Hello from {{ .Project.Name }}")
+	},
+}
+
+// Execute adds all child commands to the root command and sets flags appropriately.
+// This is called by main.main(). It only needs to happen once to the rootCmd.
+func Execute() {
+	err := rootCmd.Execute()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+{{ end -}}
@@ -0,0 +1,14 @@
+{{- if and (eq .Project.Language "go") (eq .Project.Scaffold "library") -}}
+package {{ .Project.PackageName }}_test
+
+import (
+	"fmt"
+
+	"{{ .Project.Module }}"
+)
+
+func ExampleGreet() {
+	fmt.Println({{ .Project.PackageName }}.Greet("world"))
+	// Output: Hello, world!
+}
+{{ end -}}
@@ -0,0 +1,7 @@
+{{- if eq .Project.Language "go" -}}
+module {{ .Project.Module }}
+{{- if eq .Project.Scaffold "cli" }}
+
+require github.com/spf13/cobra v1.9.1
+{{- end }}
+{{ end -}}
\ No newline at end of file
@@ -0,0 +1,9 @@
+{{- if and (eq .Project.Language "go") (eq .Project.Scaffold "library") -}}
+// Package {{ .Project.PackageName }} is the {{ .Project.Name }} library.
+package {{ .Project.PackageName }}
+
+// Greet returns a greeting for the given name.
+func Greet(name string) string {
+	return "Hello, " + name + "!"
+}
+{{ end -}}
@@ -0,0 +1,75 @@
+{{- if eq .Project.Language "go" -}}
+{{- if eq .Project.Scaffold "main" -}}
+package main
+
+func main() {}
+{{- else if eq .Project.Scaffold "cli" -}}
+package main
+
+import "{{ .Project.Module }}/cmd"
+
+func main() {
+	cmd.Execute()
+}
+{{ else if eq .Project.Scaffold "service" -}}
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	address := os.Getenv("ADDRESS")
+	if address == "" {
+		address = ":8080"
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	server := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s", address)
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("error running server: %v", err)
+			os.Exit(1)
+		}
+		return
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	log.Print("shutting down")
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("error shutting down server: %v", err)
+	}
+}
+{{ end -}}
+{{- end -}}
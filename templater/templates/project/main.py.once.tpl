@@ -0,0 +1,8 @@
+{{- if eq .Project.Language "python" -}}
+def main() -> None:
+    pass
+
+
+if __name__ == "__main__":
+    main()
+{{ end -}}
\ No newline at end of file
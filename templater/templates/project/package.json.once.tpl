@@ -0,0 +1,16 @@
+{{- if eq .Project.Language "typescript" -}}
+{
+  "name": "{{ .Project.Name }}",
+  "version": "0.0.0",
+  "private": true,
+  "license": "{{ .License }}",
+  "main": "dist/index.js",
+  "scripts": {
+    "build": "tsc",
+    "test": "node --test dist/"
+  },
+  "devDependencies": {
+    "typescript": "^5.8.2"
+  }
+}
+{{ end -}}
\ No newline at end of file
@@ -0,0 +1,12 @@
+{{- if eq .Project.Language "python" -}}
+[project]
+name = "{{ .Project.Name }}"
+version = "0.0.0"
+license = { text = "{{ .License }}" }
+requires-python = ">=3.11"
+dependencies = []
+
+[build-system]
+requires = ["setuptools>=61"]
+build-backend = "setuptools.build_meta"
+{{ end -}}
\ No newline at end of file
@@ -0,0 +1,5 @@
+{{- if eq .Project.Language "typescript" -}}
+export function main(): void {}
+
+main();
+{{ end -}}
\ No newline at end of file
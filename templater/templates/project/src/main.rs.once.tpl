@@ -0,0 +1,3 @@
+{{- if eq .Project.Language "rust" -}}
+fn main() {}
+{{ end -}}
\ No newline at end of file
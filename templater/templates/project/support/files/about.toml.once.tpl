@@ -0,0 +1,11 @@
+{{- if and .LicenseManagement (eq .Project.Language "rust") -}}
+accepted = [
+    "Apache-2.0",
+    "BSD-2-Clause",
+    "BSD-3-Clause",
+    "ISC",
+    "MIT",
+    "Unicode-3.0",
+    "Zlib",
+]
+{{ end -}}
@@ -0,0 +1,21 @@
+{{- if and .LicenseManagement (eq .Project.Language "rust") -}}
+# Licenses list
+
+<!--
+
+This list is auto generated with cargo-about
+
+run `task generate-third-party-licenses`
+
+-->
+
+## Dependencies
+
+| software     | license        |
+| :----------: | :------------: |
+{{ "{{#each licenses}}" }}
+{{ "{{#each used_by}}" }}
+| {{ "{{crate.name}}" }} {{ "{{crate.version}}" }} | {{ "{{../name}}" }} |
+{{ "{{/each}}" }}
+{{ "{{/each}}" }}
+{{ end -}}
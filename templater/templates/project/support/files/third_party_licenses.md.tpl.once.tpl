@@ -0,0 +1,19 @@
+{{- if eq .Project.Language "go" -}}
+# Licenses list
+
+<!--
+
+This list is auto generated with templater license report
+
+run `task generate-third-party-licenses`
+
+-->
+
+## Dependencies (excluding all test dependencies)
+
+| software     | license        |
+| :----------: | :------------: |
+{{ "{{ range . -}}" }}
+| {{ "{{ .Name }}" }} | [{{ "{{ .LicenseName }}" }}]({{ "{{ .LicenseURL }}" }}) |
+{{ "{{ end }}" }}
+{{ end -}}
\ No newline at end of file
@@ -0,0 +1,46 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package templates
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Scaffold is the kind of Go skeleton rendered for a project.
+type Scaffold string
+
+const (
+	// ScaffoldMain renders an empty main package.
+	ScaffoldMain Scaffold = "main"
+	// ScaffoldCLI renders a cobra based command line application.
+	ScaffoldCLI Scaffold = "cli"
+	// ScaffoldLibrary renders a library package with an example test.
+	ScaffoldLibrary Scaffold = "library"
+	// ScaffoldService renders an HTTP service with graceful shutdown.
+	ScaffoldService Scaffold = "service"
+)
+
+// Scaffolds are all of the supported project scaffolds.
+var Scaffolds = []Scaffold{ScaffoldMain, ScaffoldCLI, ScaffoldLibrary, ScaffoldService}
+
+// ParseScaffold normalizes the given scaffold name and returns an error
+// if it isn't supported.
+func ParseScaffold(name string) (Scaffold, error) {
+	scaffold := Scaffold(strings.ToLower(strings.TrimSpace(name)))
+	if !slices.Contains(Scaffolds, scaffold) {
+		names := []string{}
+		for _, scaffold := range Scaffolds {
+			names = append(names, string(scaffold))
+		}
+		return "", fmt.Errorf("unsupported scaffold %q, must be one of %s", name, strings.Join(names, ", "))
+	}
+	return scaffold, nil
+}
+
+// IsExecutable returns whether the scaffold builds a binary.
+func (s Scaffold) IsExecutable() bool {
+	return s != ScaffoldLibrary
+}
@@ -0,0 +1,29 @@
+// Copyright (c) Andrew Stucki
+// SPDX-License-Identifier: MIT
+
+package templates
+
+import (
+	"runtime/debug"
+	"strings"
+)
+
+// templaterModule is the module generated tasks and workflows run
+// templater from.
+const templaterModule = "github.com/andrewstucki/actions-testing/templater"
+
+// buildVersion returns the version templater was built at so generated
+// repositories keep running the same templater, falling back to latest
+// for builds without a usable module version, such as a local checkout.
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Path != templaterModule {
+		return "latest"
+	}
+
+	version := info.Main.Version
+	if version == "" || version == "(devel)" || strings.Contains(version, "+") {
+		return "latest"
+	}
+	return version
+}